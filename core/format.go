@@ -0,0 +1,18 @@
+// core/format.go
+package core
+
+import "fmt"
+
+// FormatSize 把字节数格式化为易读的大小，供各个工具显示文件大小
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -12,6 +12,12 @@ type Tool interface {
 	Destroy()
 }
 
+// DropTarget 是可以接收从系统拖入的文件的工具。窗口只有一个拖放回调，
+// 由主界面统一注册，只转发给当前选中的标签页中的工具。
+type DropTarget interface {
+	OnDropped(pos fyne.Position, uris []fyne.URI)
+}
+
 // ToolFactory 函数类型保持不变
 type ToolFactory func() Tool
 
@@ -5,6 +5,7 @@ go 1.24.5
 require (
 	fyne.io/fyne/v2 v2.6.2
 	github.com/go-creed/sat v1.0.1
+	github.com/mozillazg/go-pinyin v0.21.0
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	golang.org/x/image v0.29.0
 )
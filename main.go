@@ -2,11 +2,15 @@
 package main
 
 import (
+	"os"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 
 	// 导入本地包
 	appTheme "yanshu-toolkit/theme"
+	"yanshu-toolkit/tools/locallauncher"
+	"yanshu-toolkit/tools/renamer_tool"
 	"yanshu-toolkit/ui"
 
 	// 只需导入 tools 包，它会通过自己的 init.go 文件自动注册所有工具
@@ -14,6 +18,11 @@ import (
 )
 
 func main() {
+	// 命令行子命令: yanshu-toolkit rename --preset <名称> ...
+	if len(os.Args) > 1 && os.Args[1] == "rename" {
+		os.Exit(renamer_tool.RunCLI(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	myApp := app.NewWithID("com.yanshu.toolkit")
 	myApp.Settings().SetTheme(appTheme.NewLightTheme()) // 初始设置为亮色主题
 
@@ -23,6 +32,8 @@ func main() {
 	// 【修改点】: 将 myWindow 传递给布局函数
 	mainLayout := ui.CreateMainWindowLayout(myApp, myWindow)
 	myWindow.SetContent(mainLayout)
+	locallauncher.InstallPalette(myWindow)     // Ctrl+Space 快速启动
+	locallauncher.InstallTray(myApp, myWindow) // 托盘菜单和全局快捷键
 
 	myWindow.ShowAndRun()
 }
@@ -0,0 +1,300 @@
+package duplicate_finder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// --- 选择保留哪一份 ---
+
+const (
+	keepOldest       = "oldest"
+	keepShortestPath = "shortest"
+	keepInFolder     = "folder"
+)
+
+var keepRules = []string{keepOldest, keepShortestPath, keepInFolder}
+var keepRuleLabels = []string{"保留最早修改的", "保留路径最短的", "保留位于指定文件夹中的"}
+
+// applyKeepRule 为每组选出一个要保留的文件，其余的标记为不保留。
+// "指定文件夹" 规则在组内没有位于该文件夹中的文件时退回为保留最早修改的。
+func applyKeepRule(groups []*dupGroup, rule, folder string) {
+	folder = filepath.Clean(folder) + string(filepath.Separator)
+	for _, g := range groups {
+		best := g.Files[0]
+		for _, f := range g.Files[1:] {
+			if keepBetter(f, best, rule, folder) {
+				best = f
+			}
+		}
+		for _, f := range g.Files {
+			f.Keep = f == best
+		}
+	}
+}
+
+func keepBetter(a, b *dupFile, rule, folder string) bool {
+	switch rule {
+	case keepShortestPath:
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path)
+		}
+	case keepInFolder:
+		inA, inB := strings.HasPrefix(a.Path, folder), strings.HasPrefix(b.Path, folder)
+		if inA != inB {
+			return inA
+		}
+	}
+	return a.ModTime.Before(b.ModTime)
+}
+
+// --- 处理未保留的文件 ---
+
+const (
+	actionDelete   = "delete"
+	actionMove     = "move"
+	actionHardLink = "hardlink"
+)
+
+var actions = []string{actionDelete, actionMove, actionHardLink}
+var actionLabels = []string{"删除", "移动到文件夹", "替换为硬链接"}
+
+// journalEntry 记录对一个文件的操作，足以撤销它。
+// 删除和硬链接无法直接恢复，撤销时从保留的那一份 (内容完全相同) 复制回来。
+type journalEntry struct {
+	Path    string      `json:"path"`
+	Source  string      `json:"source"`             // 保留的那一份
+	Hash    string      `json:"hash,omitempty"`     // 保留的那一份的内容哈希，撤销前确认它没有改变
+	MovedTo string      `json:"moved_to,omitempty"` // 移动操作的目标路径
+	ModTime time.Time   `json:"mod_time"`
+	Mode    os.FileMode `json:"mode"`
+}
+
+// journalBatch 是一次 "执行" 操作的记录
+type journalBatch struct {
+	Time    time.Time      `json:"time"`
+	Action  string         `json:"action"`
+	Entries []journalEntry `json:"entries"`
+}
+
+const journalDir = "./data/duplicate_finder"
+
+var journalPath = filepath.Join(journalDir, "journal.json")
+
+func loadJournal() ([]journalBatch, error) {
+	data, err := os.ReadFile(journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var batches []journalBatch
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return nil, fmt.Errorf("无法解析操作记录: %v", err)
+	}
+	return batches, nil
+}
+
+func saveJournal(batches []journalBatch) error {
+	if err := os.MkdirAll(journalDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(journalPath, data, 0644)
+}
+
+// processGroups 对每组中未保留的文件执行 action，返回操作记录和失败信息。
+// 没有任何保留文件的组会被跳过，避免误删所有副本；
+// 保留的文件不存在或在扫描后发生了变化时，整组跳过。
+func processGroups(groups []*dupGroup, action, moveDir string) (journalBatch, []string) {
+	batch := journalBatch{Time: time.Now(), Action: action}
+	var problems []string
+	for _, g := range groups {
+		var keep *dupFile
+		for _, f := range g.Files {
+			if f.Keep {
+				keep = f
+				break
+			}
+		}
+		if keep == nil {
+			problems = append(problems, fmt.Sprintf("%s 等 %d 个文件: 没有选择要保留的文件，已跳过", g.Files[0].Path, len(g.Files)))
+			continue
+		}
+		if _, err := checkUnchanged(keep); err != nil {
+			problems = append(problems, fmt.Sprintf("%s 等 %d 个文件: 保留的文件 %s %v，已跳过", g.Files[0].Path, len(g.Files), keep.Path, err))
+			continue
+		}
+		for _, f := range g.Files {
+			if f.Keep {
+				continue
+			}
+			entry, err := processFile(f, keep, action, moveDir)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", f.Path, err))
+				continue
+			}
+			batch.Entries = append(batch.Entries, entry)
+		}
+	}
+	return batch, problems
+}
+
+func processFile(f, keep *dupFile, action, moveDir string) (journalEntry, error) {
+	info, err := checkUnchanged(f)
+	if err != nil {
+		return journalEntry{}, err
+	}
+	entry := journalEntry{Path: f.Path, Source: keep.Path, Hash: keep.Hash, ModTime: info.ModTime(), Mode: info.Mode().Perm()}
+	switch action {
+	case actionDelete:
+		err = os.Remove(f.Path)
+	case actionMove:
+		entry.MovedTo = uniquePath(filepath.Join(moveDir, filepath.Base(f.Path)))
+		err = moveFile(f.Path, entry.MovedTo)
+	case actionHardLink:
+		// 先在同一文件夹中创建链接，再替换原文件，失败时原文件保持不变
+		tmp := f.Path + ".yanshu-link"
+		if err = os.Link(keep.Path, tmp); err == nil {
+			if err = os.Rename(tmp, f.Path); err != nil {
+				os.Remove(tmp)
+			}
+		}
+	default:
+		err = fmt.Errorf("未知的操作: %s", action)
+	}
+	return entry, err
+}
+
+// checkUnchanged 确认文件的大小、修改时间和内容与扫描时相同
+func checkUnchanged(f *dupFile) (os.FileInfo, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("已不存在: %v", err)
+	}
+	if info.Size() != f.Size || !info.ModTime().Equal(f.ModTime) {
+		return nil, errors.New("在扫描后被修改过")
+	}
+	if f.Hash != "" {
+		h, err := fullHash(f)
+		if err != nil {
+			return nil, err
+		}
+		if h != f.Hash {
+			return nil, errors.New("内容在扫描后发生了变化")
+		}
+	}
+	return info, nil
+}
+
+// undoBatch 撤销一次操作，返回无法撤销的条目 (保留在记录中) 和失败信息
+func undoBatch(batch journalBatch) ([]journalEntry, []string) {
+	var remaining []journalEntry
+	var problems []string
+	for _, e := range batch.Entries {
+		var err error
+		switch batch.Action {
+		case actionMove:
+			err = moveFile(e.MovedTo, e.Path)
+		case actionDelete, actionHardLink:
+			err = restoreCopy(e)
+		}
+		if err != nil {
+			remaining = append(remaining, e)
+			problems = append(problems, fmt.Sprintf("%s: %v", e.Path, err))
+		}
+	}
+	return remaining, problems
+}
+
+// restoreCopy 从保留的文件复制出独立的副本，恢复原来的修改时间和权限
+func restoreCopy(e journalEntry) error {
+	src, err := os.Stat(e.Source)
+	if err != nil {
+		return fmt.Errorf("保留的文件 %s 已不存在: %v", e.Source, err)
+	}
+	if e.Hash != "" {
+		h, err := fullHash(&dupFile{Path: e.Source, Size: src.Size()})
+		if err != nil {
+			return err
+		}
+		if h != e.Hash {
+			return fmt.Errorf("保留的文件 %s 的内容已改变", e.Source)
+		}
+	}
+	// 硬链接需要先移除，否则写入会修改保留的文件
+	if info, err := os.Lstat(e.Path); err == nil {
+		if !os.SameFile(info, src) {
+			return errors.New("原位置已存在其他文件")
+		}
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+	}
+	if err := copyFile(e.Source, e.Path, e.Mode); err != nil {
+		return err
+	}
+	return os.Chtimes(e.Path, e.ModTime, e.ModTime)
+}
+
+// moveFile 移动文件，跨磁盘时改为复制后删除
+func moveFile(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("目标 %s 已存在", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := copyFile(src, dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	os.Chtimes(dst, info.ModTime(), info.ModTime())
+	return os.Remove(src)
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// uniquePath 在 path 已存在时添加 " (2)"、" (3)" 等后缀
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			return path
+		}
+		path = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
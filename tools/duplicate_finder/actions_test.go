@@ -0,0 +1,243 @@
+package duplicate_finder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyKeepRule(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := func() []*dupFile {
+		return []*dupFile{
+			{Path: filepath.FromSlash("/data/photos/backup/a.jpg"), ModTime: base.Add(time.Hour)},
+			{Path: filepath.FromSlash("/data/a.jpg"), ModTime: base.Add(2 * time.Hour)},
+			{Path: filepath.FromSlash("/data/photos/a.jpg"), ModTime: base},
+			{Path: filepath.FromSlash("/data/b.jpg"), ModTime: base.Add(3 * time.Hour)},
+		}
+	}
+	tests := []struct {
+		name   string
+		rule   string
+		folder string
+		want   int // 期望保留的文件在 files() 中的下标
+	}{
+		{name: "最早修改的", rule: keepOldest, want: 2},
+		{name: "路径最短的", rule: keepShortestPath, want: 1}, // 与 b.jpg 一样短，a.jpg 修改得更早
+		{name: "指定文件夹", rule: keepInFolder, folder: "/data/photos/backup", want: 0},
+		{name: "指定文件夹的子文件夹也算", rule: keepInFolder, folder: "/data/photos/", want: 2},
+		{name: "文件夹名称只是前缀时不算", rule: keepInFolder, folder: "/data/photo", want: 2},
+		{name: "组内没有位于文件夹中的文件", rule: keepInFolder, folder: "/other", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &dupGroup{Size: 1, Files: files()}
+			applyKeepRule([]*dupGroup{g}, tt.rule, filepath.FromSlash(tt.folder))
+			for i, f := range g.Files {
+				if f.Keep != (i == tt.want) {
+					t.Errorf("%s: Keep = %v, 期望保留 %s", f.Path, f.Keep, g.Files[tt.want].Path)
+				}
+			}
+			if got := g.reclaimable(); got != 3 {
+				t.Errorf("reclaimable() = %d, 期望 3", got)
+			}
+		})
+	}
+}
+
+// scanFile 像扫描时一样记录文件的大小、修改时间和哈希
+func scanFile(t *testing.T, path string, keep bool) *dupFile {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &dupFile{Path: path, Size: info.Size(), ModTime: info.ModTime(), Keep: keep}
+	if f.Hash, err = fullHash(f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// rewrite 修改文件内容，但保持大小和修改时间不变
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessGroupsAndUndo(t *testing.T) {
+	mtime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.Local)
+	for _, action := range actions {
+		t.Run(action, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"keep.txt": "same", "sub/dup.txt": "same", "dup2.txt": "same"})
+			keepPath, dupPath, dup2Path := filepath.Join(dir, "keep.txt"), filepath.Join(dir, "sub", "dup.txt"), filepath.Join(dir, "dup2.txt")
+			os.Chtimes(dupPath, mtime, mtime)
+			moveDir := filepath.Join(dir, "moved")
+			g := &dupGroup{Size: 4, Files: []*dupFile{scanFile(t, keepPath, true), scanFile(t, dupPath, false), scanFile(t, dup2Path, false)}}
+
+			batch, problems := processGroups([]*dupGroup{g}, action, moveDir)
+			if len(problems) != 0 || len(batch.Entries) != 2 || batch.Action != action {
+				t.Fatalf("processGroups() = %+v, %v", batch, problems)
+			}
+			keepInfo, _ := os.Stat(keepPath)
+			switch action {
+			case actionDelete:
+				if _, err := os.Stat(dupPath); !os.IsNotExist(err) {
+					t.Errorf("文件没有被删除: %v", err)
+				}
+			case actionMove:
+				if data, err := os.ReadFile(filepath.Join(moveDir, "dup.txt")); err != nil || string(data) != "same" {
+					t.Errorf("文件没有被移动: %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(moveDir, "dup2.txt")); err != nil {
+					t.Errorf("文件没有被移动: %v", err)
+				}
+			case actionHardLink:
+				if info, err := os.Stat(dupPath); err != nil || !os.SameFile(info, keepInfo) {
+					t.Errorf("文件没有被替换为硬链接: %v", err)
+				}
+			}
+
+			remaining, problems := undoBatch(batch)
+			if len(remaining) != 0 || len(problems) != 0 {
+				t.Fatalf("undoBatch() = %v, %v", remaining, problems)
+			}
+			for _, p := range []string{dupPath, dup2Path} {
+				info, err := os.Stat(p)
+				if err != nil {
+					t.Fatalf("没有恢复 %s: %v", p, err)
+				}
+				if data, _ := os.ReadFile(p); string(data) != "same" {
+					t.Errorf("%s 的内容 = %q", p, data)
+				}
+				if os.SameFile(info, keepInfo) {
+					t.Errorf("%s 恢复后仍然是保留文件的硬链接", p)
+				}
+			}
+			if info, _ := os.Stat(dupPath); !info.ModTime().Equal(mtime) {
+				t.Errorf("修改时间 = %v, 期望 %v", info.ModTime(), mtime)
+			}
+			if data, _ := os.ReadFile(keepPath); string(data) != "same" {
+				t.Errorf("保留的文件被修改: %q", data)
+			}
+		})
+	}
+}
+
+func TestProcessGroupsSkipsChangedFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		keep        bool                                         // 是否选择了保留的文件
+		change      func(t *testing.T, keepPath, dupPath string) // 扫描之后对文件的修改
+		wantProblem string
+		wantKept    bool // 保留的文件仍然存在
+	}{
+		{
+			name:        "没有选择保留的文件",
+			change:      func(*testing.T, string, string) {},
+			wantProblem: "没有选择要保留的文件",
+			wantKept:    true,
+		},
+		{
+			name:        "保留的文件已被删除",
+			keep:        true,
+			change:      func(t *testing.T, keepPath, _ string) { os.Remove(keepPath) },
+			wantProblem: "已不存在",
+		},
+		{
+			name:        "保留的文件内容改变",
+			keep:        true,
+			change:      func(t *testing.T, keepPath, _ string) { rewrite(t, keepPath, "diff") },
+			wantProblem: "内容在扫描后发生了变化",
+			wantKept:    true,
+		},
+		{
+			name: "重复的文件大小改变",
+			keep: true,
+			change: func(t *testing.T, _, dupPath string) {
+				os.WriteFile(dupPath, []byte("longer content"), 0644)
+			},
+			wantProblem: "在扫描后被修改过",
+			wantKept:    true,
+		},
+		{
+			name:        "重复的文件内容改变",
+			keep:        true,
+			change:      func(t *testing.T, _, dupPath string) { rewrite(t, dupPath, "diff") },
+			wantProblem: "内容在扫描后发生了变化",
+			wantKept:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"keep.txt": "same", "dup.txt": "same"})
+			keepPath, dupPath := filepath.Join(dir, "keep.txt"), filepath.Join(dir, "dup.txt")
+			g := &dupGroup{Size: 4, Files: []*dupFile{scanFile(t, keepPath, tt.keep), scanFile(t, dupPath, false)}}
+			tt.change(t, keepPath, dupPath)
+			before, _ := os.ReadFile(dupPath)
+
+			batch, problems := processGroups([]*dupGroup{g}, actionDelete, "")
+			if len(batch.Entries) != 0 {
+				t.Errorf("处理了 %d 个文件, 期望跳过", len(batch.Entries))
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.wantProblem) {
+				t.Errorf("problems = %q, 期望包含 %q", problems, tt.wantProblem)
+			}
+			if data, err := os.ReadFile(dupPath); err != nil || string(data) != string(before) {
+				t.Errorf("重复的文件被改动: %q, %v", data, err)
+			}
+			if _, err := os.Stat(keepPath); (err == nil) != tt.wantKept {
+				t.Errorf("保留的文件: %v", err)
+			}
+		})
+	}
+}
+
+func TestUndoBatchChangedSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"keep.txt": "same", "dup.txt": "same", "other.txt": "same"})
+	keepPath := filepath.Join(dir, "keep.txt")
+	g := &dupGroup{Size: 4, Files: []*dupFile{
+		scanFile(t, keepPath, true),
+		scanFile(t, filepath.Join(dir, "dup.txt"), false),
+		scanFile(t, filepath.Join(dir, "other.txt"), false),
+	}}
+	batch, problems := processGroups([]*dupGroup{g}, actionDelete, "")
+	if len(batch.Entries) != 2 || len(problems) != 0 {
+		t.Fatalf("processGroups() = %+v, %v", batch, problems)
+	}
+
+	// 原位置已被其他文件占用的条目无法撤销，其余的照常恢复
+	writeFiles(t, dir, map[string]string{"other.txt": "new file"})
+	remaining, problems := undoBatch(batch)
+	if len(remaining) != 1 || remaining[0].Path != filepath.Join(dir, "other.txt") || len(problems) != 1 {
+		t.Fatalf("undoBatch() = %+v, %v", remaining, problems)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "dup.txt")); string(data) != "same" {
+		t.Errorf("dup.txt = %q", data)
+	}
+
+	// 保留的文件内容改变后，不能用它恢复
+	os.Remove(filepath.Join(dir, "other.txt"))
+	rewrite(t, keepPath, "diff")
+	remaining, problems = undoBatch(journalBatch{Action: actionDelete, Entries: remaining})
+	if len(remaining) != 1 || len(problems) != 1 || !strings.Contains(problems[0], "内容已改变") {
+		t.Errorf("undoBatch() = %+v, %v", remaining, problems)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); !os.IsNotExist(err) {
+		t.Errorf("用改变后的内容恢复了文件: %v", err)
+	}
+}
@@ -0,0 +1,472 @@
+package duplicate_finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yanshu-toolkit/core"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/skratchdot/open-golang/open"
+)
+
+func New() core.Tool {
+	return &duplicateFinderTool{}
+}
+
+func init() {
+	core.RegisterFactory(New)
+}
+
+type duplicateFinderTool struct {
+	win fyne.Window
+
+	folders []string
+	groups  []*dupGroup
+	rows    []dupRow // groups 展开后显示在列表中的行
+
+	cancel context.CancelFunc // 正在进行的扫描
+
+	folderList   *widget.List
+	resultList   *widget.List
+	minSizeEntry *widget.Entry
+	scanBtn      *widget.Button
+	cancelBtn    *widget.Button
+	executeBtn   *widget.Button
+	undoBtn      *widget.Button
+	progressBar  *widget.ProgressBar
+	statusLabel  *widget.Label
+	summaryLabel *widget.Label
+}
+
+// dupRow 是结果列表中的一行：组标题 (file 为 nil) 或组内的一个文件
+type dupRow struct {
+	group *dupGroup
+	file  *dupFile
+}
+
+func (t *duplicateFinderTool) Title() string       { return "重复文件查找" }
+func (t *duplicateFinderTool) Icon() fyne.Resource { return theme.ContentCopyIcon() }
+func (t *duplicateFinderTool) Category() string    { return "文件管理" }
+
+func (t *duplicateFinderTool) Destroy() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+func (t *duplicateFinderTool) View(win fyne.Window) fyne.CanvasObject {
+	t.win = win
+	split := container.NewHSplit(t.createFolderPanel(), t.createResultPanel())
+	split.SetOffset(0.3)
+	return container.NewBorder(nil, t.createActionPanel(), nil, nil, split)
+}
+
+// --- 左侧: 要扫描的文件夹 ---
+func (t *duplicateFinderTool) createFolderPanel() fyne.CanvasObject {
+	t.folderList = widget.NewList(
+		func() int { return len(t.folders) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), nil), widget.NewLabel(""))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			label.SetText(t.folders[i])
+			label.Truncation = fyne.TextTruncateEllipsis
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				t.folders = append(t.folders[:i], t.folders[i+1:]...)
+				t.folderList.Refresh()
+			}
+		},
+	)
+	addBtn := widget.NewButtonWithIcon("添加文件夹", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			for _, f := range t.folders {
+				if f == uri.Path() {
+					return
+				}
+			}
+			t.folders = append(t.folders, uri.Path())
+			t.folderList.Refresh()
+		}, t.win)
+	})
+	t.minSizeEntry = widget.NewEntry()
+	t.minSizeEntry.SetText("1")
+	t.scanBtn = widget.NewButtonWithIcon("开始查找", theme.SearchIcon(), t.startScan)
+	t.scanBtn.Importance = widget.HighImportance
+	t.cancelBtn = widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() {
+		if t.cancel != nil {
+			t.cancel()
+		}
+	})
+	t.cancelBtn.Disable()
+
+	top := container.NewVBox(widget.NewLabelWithStyle("扫描的文件夹", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}), addBtn)
+	bottom := container.NewVBox(
+		widget.NewForm(widget.NewFormItem("最小文件 (KB):", t.minSizeEntry)),
+		container.NewGridWithColumns(2, t.scanBtn, t.cancelBtn),
+	)
+	return container.NewBorder(top, bottom, nil, nil, t.folderList)
+}
+
+// --- 右侧: 查找结果 ---
+func (t *duplicateFinderTool) createResultPanel() fyne.CanvasObject {
+	t.resultList = widget.NewList(
+		func() int { return len(t.rows) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewCheck("保留", nil), nil, label)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			row := t.rows[i]
+			box := o.(*fyne.Container)
+			label := box.Objects[0].(*widget.Label)
+			check := box.Objects[1].(*widget.Check)
+			if row.file == nil {
+				check.Hide()
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(fmt.Sprintf("%d 个相同文件，每个 %s", len(row.group.Files), core.FormatSize(row.group.Size)))
+				return
+			}
+			check.Show()
+			check.OnChanged = nil
+			check.SetChecked(row.file.Keep)
+			check.OnChanged = func(keep bool) {
+				row.file.Keep = keep
+				t.updateSummary()
+			}
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText(fmt.Sprintf("%s    %s", row.file.Path, row.file.ModTime.Format("2006-01-02 15:04")))
+		},
+	)
+	t.resultList.OnSelected = func(id widget.ListItemID) {
+		t.resultList.Unselect(id)
+		if row := t.rows[id]; row.file != nil {
+			if err := open.Start(row.file.Path); err != nil {
+				dialog.ShowError(err, t.win)
+			}
+		}
+	}
+	t.summaryLabel = widget.NewLabel("选择文件夹后点击 \"开始查找\"。单击文件可以打开它。")
+	return container.NewBorder(t.summaryLabel, nil, nil, nil, t.resultList)
+}
+
+// --- 底部: 保留规则和操作 ---
+func (t *duplicateFinderTool) createActionPanel() fyne.CanvasObject {
+	keepFolderEntry := widget.NewEntry()
+	keepFolderEntry.SetPlaceHolder("保留位于此文件夹中的文件")
+	keepFolderEntry.Disable()
+	keepSelect := widget.NewSelect(keepRuleLabels, func(string) {})
+	keepSelect.OnChanged = func(string) {
+		if keepRules[keepSelect.SelectedIndex()] == keepInFolder {
+			keepFolderEntry.Enable()
+		} else {
+			keepFolderEntry.Disable()
+		}
+	}
+	keepSelect.SetSelectedIndex(0)
+	applyKeepBtn := widget.NewButton("应用规则", func() {
+		rule := keepRules[keepSelect.SelectedIndex()]
+		if rule == keepInFolder && strings.TrimSpace(keepFolderEntry.Text) == "" {
+			dialog.ShowInformation("提示", "请填写要保留的文件所在的文件夹。", t.win)
+			return
+		}
+		applyKeepRule(t.groups, rule, strings.TrimSpace(keepFolderEntry.Text))
+		t.resultList.Refresh()
+		t.updateSummary()
+	})
+
+	moveDirEntry := widget.NewEntry()
+	moveDirEntry.SetPlaceHolder("移动到的文件夹")
+	moveDirEntry.Disable()
+	actionSelect := widget.NewSelect(actionLabels, func(string) {})
+	actionSelect.OnChanged = func(string) {
+		if actions[actionSelect.SelectedIndex()] == actionMove {
+			moveDirEntry.Enable()
+		} else {
+			moveDirEntry.Disable()
+		}
+	}
+	actionSelect.SetSelectedIndex(0)
+	t.executeBtn = widget.NewButtonWithIcon("处理未保留的文件", theme.ConfirmIcon(), func() {
+		t.execute(actions[actionSelect.SelectedIndex()], strings.TrimSpace(moveDirEntry.Text))
+	})
+	t.executeBtn.Importance = widget.DangerImportance
+	t.undoBtn = widget.NewButtonWithIcon("撤销上次操作", theme.ContentUndoIcon(), t.undoLast)
+	t.refreshUndoButton()
+
+	t.progressBar = widget.NewProgressBar()
+	t.progressBar.Hide()
+	t.statusLabel = widget.NewLabel("")
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, container.NewHBox(widget.NewLabel("保留规则:"), keepSelect), applyKeepBtn, keepFolderEntry),
+		container.NewBorder(nil, nil, container.NewHBox(widget.NewLabel("操作:"), actionSelect), container.NewHBox(t.executeBtn, t.undoBtn), moveDirEntry),
+		container.NewBorder(nil, nil, nil, t.progressBar, t.statusLabel),
+	)
+}
+
+func (t *duplicateFinderTool) setResults(groups []*dupGroup) {
+	t.groups = groups
+	t.rows = t.rows[:0]
+	for _, g := range groups {
+		t.rows = append(t.rows, dupRow{group: g})
+		for _, f := range g.Files {
+			t.rows = append(t.rows, dupRow{group: g, file: f})
+		}
+	}
+	t.resultList.Refresh()
+	t.updateSummary()
+}
+
+func (t *duplicateFinderTool) updateSummary() {
+	var files int
+	var reclaim int64
+	for _, g := range t.groups {
+		files += len(g.Files)
+		reclaim += g.reclaimable()
+	}
+	t.summaryLabel.SetText(fmt.Sprintf("找到 %d 组重复文件，共 %d 个文件。处理未保留的文件可以释放 %s。", len(t.groups), files, core.FormatSize(reclaim)))
+}
+
+func (t *duplicateFinderTool) setBusy(busy bool) {
+	if busy {
+		t.scanBtn.Disable()
+		t.executeBtn.Disable()
+		t.undoBtn.Disable()
+		t.cancelBtn.Enable()
+		t.progressBar.SetValue(0)
+		t.progressBar.Show()
+	} else {
+		t.scanBtn.Enable()
+		t.executeBtn.Enable()
+		t.refreshUndoButton()
+		t.cancelBtn.Disable()
+		t.progressBar.Hide()
+	}
+}
+
+func (t *duplicateFinderTool) startScan() {
+	if len(t.folders) == 0 {
+		dialog.ShowInformation("提示", "请先添加要扫描的文件夹。", t.win)
+		return
+	}
+	minKB, err := strconv.ParseInt(strings.TrimSpace(t.minSizeEntry.Text), 10, 64)
+	if err != nil || minKB < 0 {
+		dialog.ShowError(errors.New("最小文件大小必须是非负整数"), t.win)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.setBusy(true)
+	t.setResults(nil)
+	folders := append([]string(nil), t.folders...)
+
+	go func() {
+		// 进度回调可能来自多个哈希 goroutine，限频后再交给 UI 线程
+		var mu sync.Mutex
+		var last time.Time
+		progress := func(phase string, done, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			if time.Since(last) < 100*time.Millisecond {
+				return
+			}
+			last = time.Now()
+			fyne.Do(func() {
+				if total > 0 {
+					t.statusLabel.SetText(fmt.Sprintf("%s... %d/%d", phase, done, total))
+					t.progressBar.SetValue(float64(done) / float64(total))
+				} else {
+					t.statusLabel.SetText(fmt.Sprintf("%s... 已找到 %d 个", phase, done))
+				}
+			})
+		}
+		start := time.Now()
+		groups, err := findDuplicates(ctx, folders, minKB*1024, progress)
+		if err == nil {
+			applyKeepRule(groups, keepOldest, "")
+		}
+		fyne.Do(func() {
+			cancel()
+			t.cancel = nil
+			t.setBusy(false)
+			switch {
+			case errors.Is(err, context.Canceled):
+				t.statusLabel.SetText("查找已取消。")
+			case err != nil:
+				t.statusLabel.SetText("查找失败。")
+				dialog.ShowError(err, t.win)
+			default:
+				t.statusLabel.SetText(fmt.Sprintf("查找完成，用时 %s。", time.Since(start).Round(time.Second/10)))
+				t.setResults(groups)
+			}
+		})
+	}()
+}
+
+func (t *duplicateFinderTool) execute(action, moveDir string) {
+	if len(t.groups) == 0 {
+		dialog.ShowInformation("提示", "没有可处理的重复文件。", t.win)
+		return
+	}
+	if action == actionMove && moveDir == "" {
+		dialog.ShowInformation("提示", "请填写要移动到的文件夹。", t.win)
+		return
+	}
+	count := 0
+	for _, g := range t.groups {
+		for _, f := range g.Files {
+			if !f.Keep {
+				count++
+			}
+		}
+	}
+	var label string
+	for i, a := range actions {
+		if a == action {
+			label = actionLabels[i]
+		}
+	}
+	msg := fmt.Sprintf("确定要对 %d 个未保留的文件执行 \"%s\" 吗？\n操作会记录下来，可以通过 \"撤销上次操作\" 恢复。", count, label)
+	dialog.ShowConfirm("确认操作", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		t.setBusy(true)
+		t.statusLabel.SetText("正在处理...")
+		groups := t.groups
+		snapshot := snapshotGroups(groups)
+		go func() {
+			batch, problems := processGroups(snapshot, action, moveDir)
+			var saveErr error
+			if len(batch.Entries) > 0 {
+				journal, err := loadJournal()
+				if err == nil {
+					err = saveJournal(append(journal, batch))
+				}
+				saveErr = err
+			}
+			fyne.Do(func() {
+				t.setBusy(false)
+				t.setResults(remainingGroups(groups, batch))
+				t.statusLabel.SetText(fmt.Sprintf("已处理 %d 个文件，失败 %d 个。", len(batch.Entries), len(problems)))
+				if saveErr != nil {
+					dialog.ShowError(fmt.Errorf("操作已完成，但无法保存操作记录，将无法撤销: %v", saveErr), t.win)
+				}
+				showProblems(problems, t.win)
+			})
+		}()
+	}, t.win)
+}
+
+// snapshotGroups 复制分组和其中的文件。处理在后台进行时界面上仍然可以勾选 "保留"，
+// 后台只读取确认时的副本。
+func snapshotGroups(groups []*dupGroup) []*dupGroup {
+	result := make([]*dupGroup, len(groups))
+	for i, g := range groups {
+		files := make([]*dupFile, len(g.Files))
+		for j, f := range g.Files {
+			copied := *f
+			files[j] = &copied
+		}
+		result[i] = &dupGroup{Size: g.Size, Files: files}
+	}
+	return result
+}
+
+// remainingGroups 去掉已处理的文件，只剩一个文件的组不再显示
+func remainingGroups(groups []*dupGroup, batch journalBatch) []*dupGroup {
+	handled := make(map[string]bool, len(batch.Entries))
+	for _, e := range batch.Entries {
+		handled[e.Path] = true
+	}
+	var result []*dupGroup
+	for _, g := range groups {
+		var files []*dupFile
+		for _, f := range g.Files {
+			if !handled[f.Path] {
+				files = append(files, f)
+			}
+		}
+		if len(files) > 1 {
+			g.Files = files
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+func (t *duplicateFinderTool) undoLast() {
+	journal, err := loadJournal()
+	if err != nil {
+		dialog.ShowError(err, t.win)
+		return
+	}
+	if len(journal) == 0 {
+		return
+	}
+	last := journal[len(journal)-1]
+	msg := fmt.Sprintf("撤销 %s 对 %d 个文件的操作？", last.Time.Format("2006-01-02 15:04:05"), len(last.Entries))
+	dialog.ShowConfirm("撤销操作", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		t.setBusy(true)
+		t.statusLabel.SetText("正在撤销...")
+		go func() {
+			total := len(last.Entries)
+			remaining, problems := undoBatch(last)
+			journal = journal[:len(journal)-1]
+			if len(remaining) > 0 {
+				last.Entries = remaining
+				journal = append(journal, last)
+			}
+			saveErr := saveJournal(journal)
+			fyne.Do(func() {
+				t.setBusy(false)
+				t.statusLabel.SetText(fmt.Sprintf("已恢复 %d 个文件，失败 %d 个。重新查找以更新结果。", total-len(remaining), len(problems)))
+				if saveErr != nil {
+					dialog.ShowError(saveErr, t.win)
+				}
+				showProblems(problems, t.win)
+			})
+		}()
+	}, t.win)
+}
+
+func (t *duplicateFinderTool) refreshUndoButton() {
+	journal, _ := loadJournal()
+	if len(journal) > 0 {
+		t.undoBtn.Enable()
+	} else {
+		t.undoBtn.Disable()
+	}
+}
+
+func showProblems(problems []string, win fyne.Window) {
+	if len(problems) == 0 {
+		return
+	}
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(strings.Join(problems, "\n"))
+	entry.Wrapping = fyne.TextWrapWord
+	d := dialog.NewCustom("部分文件处理失败", "关闭", container.NewScroll(entry), win)
+	d.Resize(fyne.NewSize(560, 360))
+	d.Show()
+}
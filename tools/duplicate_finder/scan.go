@@ -0,0 +1,250 @@
+package duplicate_finder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// partialHashSize 是部分哈希读取的字节数：文件开头和结尾各读取这么多
+const partialHashSize = 4096
+
+type dupFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Hash    string // 扫描时计算的完整内容哈希，处理前用来确认文件没有改变
+	Keep    bool   // 处理重复文件时保留这一份
+}
+
+// dupGroup 是一组内容完全相同的文件
+type dupGroup struct {
+	Size  int64
+	Files []*dupFile
+}
+
+// reclaimable 返回删除未保留的文件后可以释放的空间
+func (g *dupGroup) reclaimable() int64 {
+	var n int64
+	for _, f := range g.Files {
+		if !f.Keep {
+			n += g.Size
+		}
+	}
+	return n
+}
+
+// scanProgress 报告当前阶段和进度，total 为 0 表示总数未知
+type scanProgress func(phase string, done, total int)
+
+// findDuplicates 在 roots 下查找重复文件：先按大小分组，再比较开头和结尾的部分哈希，
+// 最后对仍然相同的文件计算完整哈希。哈希在多个 goroutine 中并行计算。
+// 指向同一个文件的硬链接不算重复。
+func findDuplicates(ctx context.Context, roots []string, minSize int64, progress scanProgress) ([]*dupGroup, error) {
+	bySize := make(map[int64][]*dupFile)
+	seen := make(map[string]bool)
+	found := 0
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil || !d.Type().IsRegular() {
+				return nil // 跳过无法访问的条目、文件夹和符号链接
+			}
+			if seen[p] {
+				return nil // 选择的文件夹互相包含
+			}
+			seen[p] = true
+			info, err := d.Info()
+			if err != nil || info.Size() < minSize || info.Size() == 0 {
+				return nil
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], &dupFile{Path: p, Size: info.Size(), ModTime: info.ModTime()})
+			found++
+			progress("正在扫描文件", found, 0)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var candidates [][]*dupFile
+	for _, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files)
+		}
+	}
+	candidates, err := regroup(ctx, candidates, "正在比较部分内容", progress, partialHash)
+	if err != nil {
+		return nil, err
+	}
+	// 小文件的部分哈希已经覆盖了全部内容
+	var small, large [][]*dupFile
+	for _, files := range candidates {
+		if files[0].Size <= 2*partialHashSize {
+			small = append(small, files)
+		} else {
+			large = append(large, files)
+		}
+	}
+	large, err = regroup(ctx, large, "正在比较完整内容", progress, fullHash)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(small, large...)
+
+	var groups []*dupGroup
+	for _, files := range candidates {
+		files = withoutHardLinks(files)
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		groups = append(groups, &dupGroup{Size: files[0].Size, Files: files})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Size > groups[j].Size })
+	return groups, nil
+}
+
+// regroup 对每组候选文件计算 hash，只保留哈希相同且数量大于 1 的子组
+func regroup(ctx context.Context, candidates [][]*dupFile, phase string, progress scanProgress, hash func(*dupFile) (string, error)) ([][]*dupFile, error) {
+	total := 0
+	for _, files := range candidates {
+		total += len(files)
+	}
+	hashes := make(map[*dupFile]string, total)
+	var mu sync.Mutex
+	jobs := make(chan *dupFile)
+	var wg sync.WaitGroup
+	done := 0
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				h, err := hash(f)
+				mu.Lock()
+				if err == nil {
+					hashes[f] = h // 无法读取的文件不参与比较
+				}
+				done++
+				progress(phase, done, total)
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, files := range candidates {
+		for _, f := range files {
+			select {
+			case jobs <- f:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var result [][]*dupFile
+	for _, files := range candidates {
+		byHash := make(map[string][]*dupFile)
+		var order []string
+		for _, f := range files {
+			h, ok := hashes[f]
+			if !ok {
+				continue
+			}
+			if _, exists := byHash[h]; !exists {
+				order = append(order, h)
+			}
+			f.Hash = h // 小文件的部分哈希和大文件第二次比较的哈希都是完整内容的哈希
+			byHash[h] = append(byHash[h], f)
+		}
+		for _, h := range order {
+			if len(byHash[h]) > 1 {
+				result = append(result, byHash[h])
+			}
+		}
+	}
+	return result, nil
+}
+
+// partialHash 读取文件开头和结尾的内容计算哈希，小文件直接计算完整哈希
+func partialHash(f *dupFile) (string, error) {
+	if f.Size <= 2*partialHashSize {
+		return fullHash(f)
+	}
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	buf := make([]byte, partialHashSize)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		return "", err
+	}
+	h.Write(buf)
+	if _, err := file.ReadAt(buf, f.Size-partialHashSize); err != nil {
+		return "", err
+	}
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fullHash(f *dupFile) (string, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// withoutHardLinks 去掉与组内前面的文件是同一个文件 (硬链接) 的条目
+func withoutHardLinks(files []*dupFile) []*dupFile {
+	type entry struct {
+		file *dupFile
+		info os.FileInfo
+	}
+	var kept []entry
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+		same := false
+		for _, k := range kept {
+			if os.SameFile(k.info, info) {
+				same = true
+				break
+			}
+		}
+		if !same {
+			kept = append(kept, entry{f, info})
+		}
+	}
+	result := make([]*dupFile, len(kept))
+	for i, k := range kept {
+		result[i] = k.file
+	}
+	return result
+}
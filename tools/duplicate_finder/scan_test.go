@@ -0,0 +1,124 @@
+package duplicate_finder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在 dir 中创建文件，键是以 / 分隔的相对路径
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	// 大于 2*partialHashSize 的文件，开头和结尾相同，只有中间不同
+	large := func(middle string) string {
+		pad := strings.Repeat("A", partialHashSize+100)
+		return pad + middle + pad
+	}
+	tests := []struct {
+		name    string
+		files   map[string]string
+		links   map[string]string // 硬链接 -> 已有的文件
+		minSize int64
+		want    [][]string // 每组中的相对路径，组按文件大小从大到小
+	}{
+		{
+			name:  "相同内容分为一组",
+			files: map[string]string{"a/x.txt": "hello", "b/x.txt": "hello", "c.txt": "hello", "d.txt": "world", "e.txt": "other content"},
+			want:  [][]string{{"a/x.txt", "b/x.txt", "c.txt"}},
+		},
+		{
+			name:  "按大小排序的多组",
+			files: map[string]string{"1": "aa", "2": "aa", "3": "bbbb", "4": "bbbb"},
+			want:  [][]string{{"3", "4"}, {"1", "2"}},
+		},
+		{
+			name:  "开头和结尾相同的大文件需要比较完整内容",
+			files: map[string]string{"big1": large("x"), "big2": large("y"), "big3": large("x")},
+			want:  [][]string{{"big1", "big3"}},
+		},
+		{
+			name:  "空文件不算重复",
+			files: map[string]string{"empty1": "", "empty2": ""},
+		},
+		{
+			name:    "小于最小大小的文件被忽略",
+			files:   map[string]string{"s1": "tiny", "s2": "tiny", "l1": "larger!", "l2": "larger!"},
+			minSize: 5,
+			want:    [][]string{{"l1", "l2"}},
+		},
+		{
+			name:  "指向同一个文件的硬链接不算重复",
+			files: map[string]string{"orig": "linked", "copy": "linked"},
+			links: map[string]string{"link": "orig"},
+			want:  [][]string{{"copy", "link"}}, // 按遍历顺序保留先遇到的 link
+		},
+		{
+			name:  "只有硬链接时没有重复",
+			files: map[string]string{"orig": "linked"},
+			links: map[string]string{"link": "orig"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			for link, target := range tt.links {
+				if err := os.Link(filepath.Join(dir, target), filepath.Join(dir, link)); err != nil {
+					t.Skipf("无法创建硬链接: %v", err)
+				}
+			}
+			// 重复传入同一个文件夹，文件不会被计算两次
+			groups, err := findDuplicates(context.Background(), []string{dir, dir}, tt.minSize, func(string, int, int) {})
+			if err != nil {
+				t.Fatalf("findDuplicates() 出错: %v", err)
+			}
+			var got [][]string
+			for _, g := range groups {
+				var paths []string
+				for _, f := range g.Files {
+					rel, _ := filepath.Rel(dir, f.Path)
+					paths = append(paths, filepath.ToSlash(rel))
+					content, _ := os.ReadFile(f.Path)
+					sum := sha256.Sum256(content)
+					if f.Hash != hex.EncodeToString(sum[:]) {
+						t.Errorf("%s 的 Hash 不是完整内容的哈希", rel)
+					}
+					if f.Size != g.Size || f.Size != int64(len(content)) {
+						t.Errorf("%s 的大小 = %d, 组 = %d", rel, f.Size, g.Size)
+					}
+				}
+				got = append(got, paths)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("findDuplicates() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("取消", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"a": "x", "b": "x"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := findDuplicates(ctx, []string{dir}, 0, func(string, int, int) {}); err == nil {
+			t.Error("取消后期望出错")
+		}
+	})
+}
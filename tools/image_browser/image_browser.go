@@ -214,16 +214,18 @@ func (t *imageBrowserTool) Icon() fyne.Resource { return theme.FileImageIcon() }
 // ** 修正: 修复 Category 方法的接收者语法 **
 func (t *imageBrowserTool) Category() string { return "媒体工具" }
 
+// OnDropped 显示拖入的第一个文件或文件夹
+func (t *imageBrowserTool) OnDropped(_ fyne.Position, uris []fyne.URI) {
+	if len(uris) > 0 {
+		t.handleDrop(uris[0])
+	}
+}
+
 func (t *imageBrowserTool) View(win fyne.Window) fyne.CanvasObject {
 	if t.view != nil {
 		return t.view
 	}
 	t.parentWin = win
-	t.parentWin.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
-		if len(uris) > 0 {
-			t.handleDrop(uris[0])
-		}
-	})
 	t.parentWin.Canvas().SetOnTypedKey(func(key *fyne.KeyEvent) {
 		switch key.Name {
 		case fyne.KeyLeft:
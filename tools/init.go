@@ -12,6 +12,7 @@ package tools
 // 导入路径现在和物理文件结构完全对应，消除了所有歧义。
 
 import (
+	_ "yanshu-toolkit/tools/duplicate_finder"
 	_ "yanshu-toolkit/tools/example"
 	_ "yanshu-toolkit/tools/home"
 	_ "yanshu-toolkit/tools/image_browser"
@@ -0,0 +1,253 @@
+package locallauncher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ChainStep 是启动组合中的一步，按 ID 引用其他条目，因此条目改名后组合仍然有效。
+// Name 是保存时条目的名称，只用于显示；旧版配置中没有 ID，加载时按名称找到条目后补上。
+type ChainStep struct {
+	ID    string  `json:"id,omitempty"`
+	Name  string  `json:"name"`
+	Delay float64 `json:"delay,omitempty"` // 启动前等待的秒数
+}
+
+// newEntryID 生成条目的 ID
+func newEntryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// indexByID 返回 ID 为 id 的条目的位置，找不到时返回 -1
+func (t *localLauncherTool) indexByID(id string) int {
+	if id == "" {
+		return -1
+	}
+	return slices.IndexFunc(t.configs, func(c ToolConfig) bool { return c.ID == id })
+}
+
+// ensureIDs 为没有 ID (或 ID 重复) 的条目分配 ID，把旧版按名称引用的步骤转换为按 ID 引用，
+// 并把步骤中的名称更新为条目当前的名称。有新分配的 ID 时返回 true。
+func (t *localLauncherTool) ensureIDs() (changed bool) {
+	seen := make(map[string]bool, len(t.configs))
+	for i := range t.configs {
+		if t.configs[i].ID == "" || seen[t.configs[i].ID] {
+			t.configs[i].ID = newEntryID()
+			changed = true
+		}
+		seen[t.configs[i].ID] = true
+	}
+	for i := range t.configs {
+		for j := range t.configs[i].Steps {
+			step := &t.configs[i].Steps[j]
+			if step.ID == "" {
+				if k := slices.IndexFunc(t.configs, func(c ToolConfig) bool { return c.Name == step.Name }); k >= 0 {
+					step.ID = t.configs[k].ID
+					changed = true
+				}
+			}
+			if k := t.indexByID(step.ID); k >= 0 {
+				step.Name = t.configs[k].Name
+			}
+		}
+	}
+	return changed
+}
+
+// chainsUsing 返回引用了 id 的启动组合的名称
+func (t *localLauncherTool) chainsUsing(id string) []string {
+	var names []string
+	for _, c := range t.configs {
+		if slices.ContainsFunc(c.Steps, func(s ChainStep) bool { return s.ID == id }) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// runChain 在后台执行启动组合。依次启动时，每一步在前一步启动后等待 Delay 秒再启动，
+// 脚本和嵌套的启动组合会等它们运行结束，失败时停止后续步骤；同时启动时每一步从组合开始计时。
+func (t *localLauncherTool) runChain(conf ToolConfig, chain []string) (<-chan error, error) {
+	if slices.Contains(chain, conf.ID) {
+		return nil, fmt.Errorf("启动组合 '%s' 中存在循环引用", conf.Name)
+	}
+	if len(conf.Steps) == 0 {
+		return nil, fmt.Errorf("启动组合 '%s' 没有任何步骤", conf.Name)
+	}
+	chain = append(slices.Clip(chain), conf.ID)
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if conf.Parallel {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			for _, step := range conf.Steps {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if stepErr := t.runStep(step, chain); stepErr != nil {
+						mu.Lock()
+						err = stepErr
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+		} else {
+			for _, step := range conf.Steps {
+				if err = t.runStep(step, chain); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil && len(chain) == 1 {
+			fyne.Do(func() { dialog.ShowError(fmt.Errorf("启动组合 '%s' 失败: %v", conf.Name, err), t.win) })
+		}
+		done <- err
+	}()
+	return done, nil
+}
+
+// runStep 等待步骤的延迟后在 UI 线程中启动它，并等待脚本或嵌套组合结束。在后台 goroutine 中调用。
+func (t *localLauncherTool) runStep(step ChainStep, chain []string) error {
+	time.Sleep(time.Duration(step.Delay * float64(time.Second)))
+	var done <-chan error
+	var err error
+	fyne.DoAndWait(func() {
+		index := t.indexByID(step.ID)
+		if index < 0 {
+			err = fmt.Errorf("找不到条目 '%s'，它可能已被删除", step.Name)
+			return
+		}
+		done, err = t.start(t.configs[index], chain)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", step.Name, err)
+	}
+	if done != nil {
+		if err := <-done; err != nil {
+			return fmt.Errorf("%s: %v", step.Name, err)
+		}
+	}
+	return nil
+}
+
+// chainEditor 是编辑对话框中编辑启动组合步骤的控件
+type chainEditor struct {
+	options []chainOption
+	labels  []string
+	rows    *fyne.Container
+	steps   []*chainStepRow
+}
+
+// chainOption 是步骤可以选择的条目。同名的条目在标签中加上分组以便区分。
+type chainOption struct {
+	id, name, label string
+}
+
+// chainOptions 列出除第 skip 个以外的所有条目
+func (t *localLauncherTool) chainOptions(skip int) []chainOption {
+	count := map[string]int{}
+	for _, c := range t.configs {
+		count[c.Name]++
+	}
+	used := map[string]bool{}
+	var options []chainOption
+	for i, c := range t.configs {
+		if i == skip {
+			continue
+		}
+		label := c.Name
+		if count[c.Name] > 1 {
+			label = fmt.Sprintf("%s (%s)", c.Name, c.Group)
+		}
+		for n := 2; used[label]; n++ {
+			label = fmt.Sprintf("%s (%s #%d)", c.Name, c.Group, n)
+		}
+		used[label] = true
+		options = append(options, chainOption{id: c.ID, name: c.Name, label: label})
+	}
+	return options
+}
+
+type chainStepRow struct {
+	name  *widget.Select
+	delay *widget.Entry
+}
+
+func newChainEditor(options []chainOption, steps []ChainStep) *chainEditor {
+	e := &chainEditor{options: options, rows: container.NewVBox()}
+	for _, o := range options {
+		e.labels = append(e.labels, o.label)
+	}
+	for _, s := range steps {
+		e.addRow(s)
+	}
+	return e
+}
+
+func (e *chainEditor) addRow(step ChainStep) {
+	row := &chainStepRow{name: widget.NewSelect(e.labels, nil), delay: widget.NewEntry()}
+	row.name.PlaceHolder = "选择条目"
+	if i := slices.IndexFunc(e.options, func(o chainOption) bool { return o.id == step.ID }); i >= 0 {
+		row.name.SetSelectedIndex(i)
+	}
+	row.delay.SetPlaceHolder("延迟秒数")
+	if step.Delay > 0 {
+		row.delay.SetText(strconv.FormatFloat(step.Delay, 'f', -1, 64))
+	}
+	var line *fyne.Container
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		e.steps = slices.DeleteFunc(e.steps, func(r *chainStepRow) bool { return r == row })
+		e.rows.Remove(line)
+	})
+	delayBox := container.NewGridWrap(fyne.NewSize(100, row.delay.MinSize().Height), row.delay)
+	line = container.NewBorder(nil, nil, nil, container.NewHBox(delayBox, removeBtn), row.name)
+	e.steps = append(e.steps, row)
+	e.rows.Add(line)
+}
+
+// Widget 返回步骤列表和“添加步骤”按钮
+func (e *chainEditor) Widget() fyne.CanvasObject {
+	addBtn := widget.NewButtonWithIcon("添加步骤", theme.ContentAddIcon(), func() { e.addRow(ChainStep{}) })
+	return container.NewVBox(e.rows, addBtn)
+}
+
+// Steps 返回编辑后的步骤，未选择条目或延迟不是非负数时返回错误
+func (e *chainEditor) Steps() ([]ChainStep, error) {
+	var steps []ChainStep
+	for i, row := range e.steps {
+		selected := row.name.SelectedIndex()
+		if selected < 0 {
+			return nil, fmt.Errorf("第 %d 步没有选择条目", i+1)
+		}
+		step := ChainStep{ID: e.options[selected].id, Name: e.options[selected].name}
+		if text := strings.TrimSpace(row.delay.Text); text != "" {
+			delay, err := strconv.ParseFloat(text, 64)
+			if err != nil || delay < 0 {
+				return nil, fmt.Errorf("第 %d 步的延迟 '%s' 不是有效的秒数", i+1, text)
+			}
+			step.Delay = delay
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("启动组合至少需要一个步骤")
+	}
+	return steps, nil
+}
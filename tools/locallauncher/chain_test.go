@@ -0,0 +1,60 @@
+package locallauncher
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestRunChainCycles(t *testing.T) {
+	chain := func(id string, steps ...string) ToolConfig {
+		c := ToolConfig{ID: id, Name: id, Type: ChainTool}
+		for _, s := range steps {
+			c.Steps = append(c.Steps, ChainStep{ID: s, Name: s})
+		}
+		return c
+	}
+	// 网站在测试应用中打开时什么也不做，用作不会失败的步骤
+	web := ToolConfig{ID: "w", Name: "w", Type: WebTool, Path: "https://example.com/"}
+	parallel := func(c ToolConfig) ToolConfig { c.Parallel = true; return c }
+
+	tests := []struct {
+		name    string
+		configs []ToolConfig // 运行第一个条目
+		wantErr string       // 为空表示期望成功
+	}{
+		{name: "引用自身", configs: []ToolConfig{chain("a", "a")}, wantErr: "启动组合 'a' 中存在循环引用"},
+		{name: "间接循环", configs: []ToolConfig{chain("a", "w", "b"), chain("b", "c"), chain("c", "a"), web}, wantErr: "循环引用"},
+		{name: "同时启动时的循环", configs: []ToolConfig{parallel(chain("a", "w", "b")), chain("b", "a"), web}, wantErr: "循环引用"},
+		{name: "不同分支引用同一个组合不是循环", configs: []ToolConfig{chain("a", "b", "c", "b"), chain("b", "w"), chain("c", "b", "w"), web}},
+		{name: "同时启动的分支引用同一个组合", configs: []ToolConfig{parallel(chain("a", "b", "b")), chain("b", "w"), web}},
+		{name: "没有步骤", configs: []ToolConfig{chain("a")}, wantErr: "没有任何步骤"},
+		{name: "嵌套组合没有步骤", configs: []ToolConfig{chain("a", "b"), chain("b")}, wantErr: "没有任何步骤"},
+		{name: "步骤引用的条目已删除", configs: []ToolConfig{chain("a", "gone")}, wantErr: "找不到条目 'gone'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.NewTempApp(t)
+			tool := &localLauncherTool{configs: tt.configs, win: test.NewTempWindow(t, nil)}
+			done, err := tool.runChain(tt.configs[0], nil)
+			if err == nil {
+				select {
+				case err = <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatal("启动组合没有结束")
+				}
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("runChain() 出错: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runChain() = %v, 期望错误包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
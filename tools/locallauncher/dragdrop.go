@@ -0,0 +1,240 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dropTarget 是分组视图中可以放下条目的区域：分组本身 (index 为 -1) 或分组中的一个条目
+type dropTarget struct {
+	obj   fyne.CanvasObject
+	group string
+	index int
+}
+
+// dragState 记录正在拖动的条目和跟随鼠标的提示
+type dragState struct {
+	index int
+	pos   fyne.Position
+	ghost *widget.PopUp
+}
+
+// targetAt 返回包含窗口坐标 pos 的放置区域，条目优先于分组
+func (t *localLauncherTool) targetAt(pos fyne.Position) (dropTarget, bool) {
+	driver := fyne.CurrentApp().Driver()
+	var found dropTarget
+	ok := false
+	for _, target := range t.dropTargets {
+		if !target.obj.Visible() {
+			continue
+		}
+		p, s := driver.AbsolutePositionForObject(target.obj), target.obj.Size()
+		if pos.X < p.X || pos.Y < p.Y || pos.X >= p.X+s.Width || pos.Y >= p.Y+s.Height {
+			continue
+		}
+		if !ok || target.index >= 0 {
+			found, ok = target, true
+		}
+	}
+	return found, ok
+}
+
+// onDragged 在拖动条目时显示跟随鼠标的名称
+func (t *localLauncherTool) onDragged(index int, ev *fyne.DragEvent) {
+	if len(t.dropTargets) == 0 {
+		return // 搜索结果中不能拖动
+	}
+	if t.drag == nil {
+		ghost := widget.NewPopUp(widget.NewLabel(t.configs[index].Name), t.win.Canvas())
+		t.drag = &dragState{index: index, ghost: ghost}
+		ghost.Show()
+	}
+	t.drag.pos = ev.AbsolutePosition
+	t.drag.ghost.Move(ev.AbsolutePosition.Add(fyne.NewPos(12, 12)))
+}
+
+// onDragEnd 把条目移动到放下的位置：放在条目上时插入到它的前面或后面 (按鼠标在左半边还是右半边)，
+// 放在分组的空白处时移到该分组的末尾
+func (t *localLauncherTool) onDragEnd() {
+	drag := t.drag
+	t.drag = nil
+	if drag == nil {
+		return
+	}
+	drag.ghost.Hide()
+	target, ok := t.targetAt(drag.pos)
+	if !ok || target.index == drag.index {
+		return
+	}
+	before := target.index
+	if before >= 0 {
+		p := fyne.CurrentApp().Driver().AbsolutePositionForObject(target.obj)
+		if drag.pos.X > p.X+target.obj.Size().Width/2 {
+			before++
+		}
+	}
+	t.moveEntry(drag.index, target.group, before)
+}
+
+// moveEntry 把第 from 个条目移到 group 分组，插入到 configs 的第 before 个位置之前；
+// before 为 -1 时放到分组的末尾
+func (t *localLauncherTool) moveEntry(from int, group string, before int) {
+	conf := t.configs[from]
+	conf.Group = group
+	t.configs = slices.Delete(t.configs, from, from+1)
+	if before > from {
+		before--
+	}
+	if before < 0 {
+		before = len(t.configs)
+		for i, c := range t.configs {
+			if c.Group == group {
+				before = i + 1
+			}
+		}
+	}
+	t.configs = slices.Insert(t.configs, before, conf)
+	t.ensureGroup(group)
+	t.saveConfig()
+	t.refreshUI()
+}
+
+// ensureGroup 把新分组加到 "未分组" 之前
+func (t *localLauncherTool) ensureGroup(group string) {
+	if group == defaultGroup || slices.Contains(t.groupOrder, group) {
+		return
+	}
+	if len(t.groupOrder) <= 1 {
+		t.groupOrder = []string{group, defaultGroup}
+		return
+	}
+	last := len(t.groupOrder) - 1
+	t.groupOrder = append(t.groupOrder[:last], group, t.groupOrder[last])
+}
+
+// OnDropped 把从系统拖入的文件、文件夹或网址加到放下位置所在的分组
+func (t *localLauncherTool) OnDropped(pos fyne.Position, uris []fyne.URI) {
+	group := defaultGroup
+	if target, ok := t.targetAt(pos); ok {
+		group = target.group
+	}
+	var added []string
+	for _, u := range uris {
+		conf, err := configFromURI(u)
+		if err != nil {
+			dialog.ShowError(err, t.win)
+			continue
+		}
+		conf.Group = group
+		t.configs = append(t.configs, conf)
+		added = append(added, conf.Name)
+	}
+	if len(added) == 0 {
+		return
+	}
+	t.ensureGroup(group)
+	t.saveConfig()
+	t.refreshUI()
+}
+
+// configFromURI 根据拖入的内容猜测条目类型：网址、文件夹、脚本、可执行文件，其他文件用默认程序打开
+func configFromURI(u fyne.URI) (ToolConfig, error) {
+	if u.Scheme() == "http" || u.Scheme() == "https" {
+		name := u.Authority()
+		if parsed, err := url.Parse(u.String()); err == nil && parsed.Hostname() != "" {
+			name = strings.TrimPrefix(parsed.Hostname(), "www.")
+		}
+		return ToolConfig{Name: name, Type: WebTool, Path: u.String()}, nil
+	}
+	if u.Scheme() != "file" {
+		return ToolConfig{}, fmt.Errorf("不支持拖入 %s", u.String())
+	}
+	path := u.Path()
+	info, err := os.Stat(path)
+	if err != nil {
+		return ToolConfig{}, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	conf := ToolConfig{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Path: path}
+	switch {
+	case ext == ".app":
+		conf.Type = FileTool // macOS 应用包是文件夹，交给系统打开
+	case info.IsDir():
+		conf.Type = FolderTool
+		conf.Name = filepath.Base(path)
+	case slices.Contains([]string{".sh", ".bash", ".bat", ".cmd", ".ps1", ".py"}, ext):
+		conf.Type = ScriptTool
+	case ext == ".exe" || ext == ".lnk" || ext == "" && info.Mode()&0111 != 0:
+		conf.Type = AppTool
+	default:
+		conf.Type = FileTool
+	}
+	if conf.Name == "" {
+		return ToolConfig{}, errors.New("无法识别拖入的内容: " + path)
+	}
+	conf.Path = portablePath(path)
+	return conf, nil
+}
+
+// showRenameGroupDialog 重命名分组，新名称已存在时两个分组合并
+func (t *localLauncherTool) showRenameGroupDialog(group string) {
+	entry := widget.NewEntry()
+	entry.SetText(group)
+	dialog.ShowForm("重命名分组", "确定", "取消", []*widget.FormItem{widget.NewFormItem("新名称", entry)}, func(ok bool) {
+		name := strings.TrimSpace(entry.Text)
+		if !ok || name == "" || name == group {
+			return
+		}
+		merge := slices.Contains(t.groupOrder, name)
+		for i := range t.configs {
+			if t.configs[i].Group == group {
+				t.configs[i].Group = name
+			}
+		}
+		if merge {
+			t.groupOrder = slices.DeleteFunc(t.groupOrder, func(g string) bool { return g == group })
+		} else {
+			t.groupOrder[slices.Index(t.groupOrder, group)] = name
+		}
+		t.saveConfig()
+		t.refreshUI()
+	}, t.win)
+}
+
+// showDeleteGroupDialog 删除分组，其中的条目默认移到 "未分组"，也可以一起删除
+func (t *localLauncherTool) showDeleteGroupDialog(group string) {
+	count := 0
+	for _, c := range t.configs {
+		if c.Group == group {
+			count++
+		}
+	}
+	deleteEntries := widget.NewCheck(fmt.Sprintf("同时删除分组中的 %d 个条目 (否则移到 \"%s\")", count, defaultGroup), nil)
+	content := widget.NewForm(widget.NewFormItem("", deleteEntries))
+	dialog.ShowCustomConfirm("删除分组 '"+group+"'", "删除", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if deleteEntries.Checked {
+			t.configs = slices.DeleteFunc(t.configs, func(c ToolConfig) bool { return c.Group == group })
+		} else {
+			for i := range t.configs {
+				if t.configs[i].Group == group {
+					t.configs[i].Group = defaultGroup
+				}
+			}
+		}
+		t.groupOrder = slices.DeleteFunc(t.groupOrder, func(g string) bool { return g == group })
+		t.saveConfig()
+		t.refreshUI()
+	}, t.win)
+}
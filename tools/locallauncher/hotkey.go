@@ -0,0 +1,162 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// 快捷键写作 "Ctrl+Alt+T" 的形式。Windows 上注册为系统全局快捷键 (hotkey_windows.go)，
+// 其他系统或注册失败时退化为窗口快捷键，只在工具箱窗口获得焦点时有效。
+
+// errGlobalHotkeyUnsupported 表示当前系统不支持全局快捷键
+var errGlobalHotkeyUnsupported = errors.New("当前系统不支持全局快捷键")
+
+type hotkey struct {
+	mods fyne.KeyModifier
+	key  fyne.KeyName
+}
+
+// hotkeyBinding 是一个快捷键和按下时执行的操作，action 在 UI 线程中调用
+type hotkeyBinding struct {
+	hotkey hotkey
+	id     string // 条目的 ID，唤出快捷键为空
+	label  string
+	action func()
+}
+
+var hotkeyModifiers = map[string]fyne.KeyModifier{
+	"ctrl":    fyne.KeyModifierControl,
+	"control": fyne.KeyModifierControl,
+	"alt":     fyne.KeyModifierAlt,
+	"shift":   fyne.KeyModifierShift,
+	"super":   fyne.KeyModifierSuper,
+	"win":     fyne.KeyModifierSuper,
+	"cmd":     fyne.KeyModifierSuper,
+}
+
+// parseHotkey 解析快捷键。至少需要 Ctrl、Alt 或 Super 中的一个修饰键，以免占用普通输入；
+// 按键可以是字母、数字、F1-F12 或 Space。
+func parseHotkey(s string) (hotkey, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	var hk hotkey
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if i < len(parts)-1 {
+			mod, ok := hotkeyModifiers[strings.ToLower(p)]
+			if !ok {
+				return hotkey{}, fmt.Errorf("快捷键 %q 中有未知的修饰键 %q", s, p)
+			}
+			hk.mods |= mod
+			continue
+		}
+		switch upper := strings.ToUpper(p); {
+		case len(upper) == 1 && (upper[0] >= 'A' && upper[0] <= 'Z' || upper[0] >= '0' && upper[0] <= '9'):
+			hk.key = fyne.KeyName(upper)
+		case upper == "SPACE":
+			hk.key = fyne.KeySpace
+		case len(upper) >= 2 && upper[0] == 'F' && functionKeyNumber(upper) > 0:
+			hk.key = fyne.KeyName(upper)
+		default:
+			return hotkey{}, fmt.Errorf("快捷键 %q 中的按键 %q 不受支持，可以使用字母、数字、F1-F12 或 Space", s, p)
+		}
+	}
+	if hk.mods&(fyne.KeyModifierControl|fyne.KeyModifierAlt|fyne.KeyModifierSuper) == 0 {
+		return hotkey{}, fmt.Errorf("快捷键 %q 至少需要 Ctrl、Alt 或 Win 中的一个", s)
+	}
+	return hk, nil
+}
+
+// functionKeyNumber 返回 F1-F12 的编号，其他按键返回 0
+func functionKeyNumber(key string) int {
+	var n int
+	if _, err := fmt.Sscanf(key, "F%d", &n); err != nil || n < 1 || n > 12 || key != fmt.Sprintf("F%d", n) {
+		return 0
+	}
+	return n
+}
+
+// String 返回规范的写法，用于比较两个快捷键是否相同
+func (hk hotkey) String() string {
+	var parts []string
+	for _, m := range []struct {
+		mod  fyne.KeyModifier
+		name string
+	}{{fyne.KeyModifierControl, "Ctrl"}, {fyne.KeyModifierAlt, "Alt"}, {fyne.KeyModifierShift, "Shift"}, {fyne.KeyModifierSuper, "Win"}} {
+		if hk.mods&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, string(hk.key)), "+")
+}
+
+var (
+	// windowShortcuts 是作为窗口快捷键注册的绑定，重新注册前需要移除
+	windowShortcuts []fyne.Shortcut
+	// appliedHotkeys 和 appliedErr 是上一次注册的快捷键和结果，没有变化时不重新注册
+	appliedHotkeys []string
+	appliedErr     error
+)
+
+// applyHotkeys 注册所有快捷键，替换之前注册的。返回无法注册为全局快捷键的原因，nil 表示全部成功。
+func applyHotkeys(win fyne.Window, bindings []hotkeyBinding) error {
+	keys := make([]string, len(bindings))
+	for i, b := range bindings {
+		keys[i] = b.hotkey.String() + "\x00" + b.id + "\x00" + b.label
+	}
+	if appliedHotkeys != nil && slices.Equal(keys, appliedHotkeys) {
+		return appliedErr
+	}
+	appliedHotkeys = keys
+
+	for _, s := range windowShortcuts {
+		win.Canvas().RemoveShortcut(s)
+	}
+	windowShortcuts = nil
+
+	errs := registerGlobalHotkeys(bindings)
+	var problems []error
+	for i, b := range bindings {
+		if errs[i] == nil {
+			continue
+		}
+		if !errors.Is(errs[i], errGlobalHotkeyUnsupported) {
+			log.Printf("注册全局快捷键 %s (%s) 失败: %v", b.hotkey, b.label, errs[i])
+		}
+		problems = append(problems, fmt.Errorf("%s (%s): %w", b.hotkey, b.label, errs[i]))
+		shortcut := &desktop.CustomShortcut{KeyName: b.hotkey.key, Modifier: b.hotkey.mods}
+		action := b.action
+		win.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) { action() })
+		windowShortcuts = append(windowShortcuts, shortcut)
+	}
+	appliedErr = errors.Join(problems...)
+	return appliedErr
+}
+
+// hotkeyConflict 检查快捷键是否已被其他条目或唤出快捷键使用，skip 是正在编辑的条目
+func (t *localLauncherTool) hotkeyConflict(hk hotkey, skip int) error {
+	if s := t.settings.SummonHotkey; s != "" {
+		if other, err := parseHotkey(s); err == nil && other == hk {
+			return fmt.Errorf("快捷键 %s 已用于唤出工具箱", hk)
+		}
+	}
+	return t.entryUsingHotkey(hk, skip)
+}
+
+// entryUsingHotkey 检查快捷键是否已被第 skip 个以外的条目使用
+func (t *localLauncherTool) entryUsingHotkey(hk hotkey, skip int) error {
+	for i, c := range t.configs {
+		if i == skip || c.Hotkey == "" {
+			continue
+		}
+		if other, err := parseHotkey(c.Hotkey); err == nil && other == hk {
+			return fmt.Errorf("快捷键 %s 已被 '%s' 使用", hk, c.Name)
+		}
+	}
+	return nil
+}
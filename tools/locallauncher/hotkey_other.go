@@ -0,0 +1,14 @@
+//go:build !windows
+
+package locallauncher
+
+const globalHotkeysSupported = false
+
+// registerGlobalHotkeys 在 Windows 以外的系统上不可用，所有快捷键都退化为窗口快捷键
+func registerGlobalHotkeys(bindings []hotkeyBinding) []error {
+	errs := make([]error, len(bindings))
+	for i := range errs {
+		errs[i] = errGlobalHotkeyUnsupported
+	}
+	return errs
+}
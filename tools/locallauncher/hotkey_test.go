@@ -0,0 +1,105 @@
+package locallauncher
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestParseHotkey(t *testing.T) {
+	const (
+		ctrl  = fyne.KeyModifierControl
+		alt   = fyne.KeyModifierAlt
+		shift = fyne.KeyModifierShift
+		super = fyne.KeyModifierSuper
+	)
+	tests := []struct {
+		in      string
+		want    hotkey
+		str     string // 规范写法
+		wantErr bool
+	}{
+		{in: "Ctrl+Alt+T", want: hotkey{ctrl | alt, "T"}, str: "Ctrl+Alt+T"},
+		{in: " alt + ctrl + t ", want: hotkey{ctrl | alt, "T"}, str: "Ctrl+Alt+T"},
+		{in: "Control+Shift+5", want: hotkey{ctrl | shift, "5"}, str: "Ctrl+Shift+5"},
+		{in: "Win+Space", want: hotkey{super, fyne.KeySpace}, str: "Win+Space"},
+		{in: "cmd+super+F12", want: hotkey{super, "F12"}, str: "Win+F12"},
+		{in: "Ctrl+f1", want: hotkey{ctrl, "F1"}, str: "Ctrl+F1"},
+		{in: "Shift+Alt+Ctrl+Win+Z", want: hotkey{ctrl | alt | shift | super, "Z"}, str: "Ctrl+Alt+Shift+Win+Z"},
+
+		{in: "", wantErr: true},
+		{in: "T", wantErr: true},          // 没有修饰键
+		{in: "Shift+T", wantErr: true},    // 只有 Shift 会占用普通输入
+		{in: "Ctrl+", wantErr: true},      // 缺少按键
+		{in: "Ctrl+Alt", wantErr: true},   // 修饰键不能作为按键
+		{in: "Hyper+T", wantErr: true},    // 未知的修饰键
+		{in: "Ctrl+T+Alt", wantErr: true}, // T 不是修饰键
+		{in: "Ctrl+F13", wantErr: true},   // 只支持 F1-F12
+		{in: "Ctrl+F0", wantErr: true},
+		{in: "Ctrl+F01", wantErr: true},
+		{in: "Ctrl+Fx", wantErr: true},
+		{in: "Ctrl+Enter", wantErr: true}, // 不支持的按键
+		{in: "Ctrl+é", wantErr: true},
+		{in: "Ctrl+-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseHotkey(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseHotkey(%q) = %v, 期望出错", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHotkey(%q) 出错: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseHotkey(%q) = %+v, 期望 %+v", tt.in, got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q, 期望 %q", got.String(), tt.str)
+			}
+			// 规范写法可以解析回同一个快捷键
+			if again, err := parseHotkey(got.String()); err != nil || again != got {
+				t.Errorf("parseHotkey(%q) = %+v, %v", got.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestHotkeyConflict(t *testing.T) {
+	tool := &localLauncherTool{
+		configs: []ToolConfig{
+			{Name: "a", Hotkey: "Ctrl+Alt+A"},
+			{Name: "b"},
+			{Name: "broken", Hotkey: "nonsense"},
+		},
+		settings: LauncherSettings{SummonHotkey: "Ctrl+Alt+Space"},
+	}
+	hk := func(s string) hotkey {
+		h, err := parseHotkey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	tests := []struct {
+		name    string
+		hotkey  string
+		skip    int
+		wantErr bool
+	}{
+		{name: "与条目相同 (写法不同)", hotkey: "alt+ctrl+a", skip: 1, wantErr: true},
+		{name: "正在编辑的条目本身", hotkey: "Ctrl+Alt+A", skip: 0},
+		{name: "与唤出快捷键相同", hotkey: "Alt+Ctrl+Space", skip: -1, wantErr: true},
+		{name: "没有冲突", hotkey: "Ctrl+Alt+B", skip: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tool.hotkeyConflict(hk(tt.hotkey), tt.skip); (err != nil) != tt.wantErr {
+				t.Errorf("hotkeyConflict(%s, %d) = %v", tt.hotkey, tt.skip, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+//go:build windows
+
+package locallauncher
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"fyne.io/fyne/v2"
+)
+
+// Windows 的全局快捷键通过 RegisterHotKey 注册到一个专用线程的消息队列，
+// WM_HOTKEY 消息只会发送到注册它的线程，因此注册和消息循环都在同一个锁定的线程中进行。
+
+const globalHotkeysSupported = true
+
+var (
+	user32                 = syscall.NewLazyDLL("user32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPeekMessageW       = user32.NewProc("PeekMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	wmQuit   = 0x0012
+	wmHotkey = 0x0312
+
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+)
+
+type winMsg struct {
+	hwnd     uintptr
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	pt       struct{ x, y int32 }
+	lPrivate uint32
+}
+
+// hotkeyThread 是当前运行的消息循环，重新注册时先结束它
+var hotkeyThread struct {
+	id   uintptr
+	done chan struct{}
+}
+
+func registerGlobalHotkeys(bindings []hotkeyBinding) []error {
+	if hotkeyThread.done != nil {
+		procPostThreadMessageW.Call(hotkeyThread.id, wmQuit, 0, 0)
+		<-hotkeyThread.done
+		hotkeyThread.done = nil
+	}
+	errs := make([]error, len(bindings))
+	if len(bindings) == 0 {
+		return errs
+	}
+
+	ready := make(chan uintptr)
+	done := make(chan struct{})
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(done)
+
+		var msg winMsg
+		// 先创建线程的消息队列，之后 PostThreadMessage 才能送达
+		procPeekMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, 0)
+		for i, b := range bindings {
+			r, _, err := procRegisterHotKey.Call(0, uintptr(i+1), winModifiers(b.hotkey.mods)|modNoRepeat, virtualKey(b.hotkey.key))
+			if r == 0 {
+				errs[i] = fmt.Errorf("可能已被其他程序占用: %v", err)
+			}
+		}
+		defer func() {
+			for i := range bindings {
+				if errs[i] == nil {
+					procUnregisterHotKey.Call(0, uintptr(i+1))
+				}
+			}
+		}()
+		id, _, _ := procGetCurrentThreadId.Call()
+		ready <- id
+
+		for {
+			r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(r) <= 0 { // WM_QUIT 或出错
+				return
+			}
+			if msg.message == wmHotkey && msg.wParam >= 1 && int(msg.wParam) <= len(bindings) {
+				fyne.Do(bindings[msg.wParam-1].action)
+			}
+		}
+	}()
+	hotkeyThread.id = <-ready
+	hotkeyThread.done = done
+	return errs
+}
+
+func winModifiers(mods fyne.KeyModifier) uintptr {
+	var m uintptr
+	if mods&fyne.KeyModifierAlt != 0 {
+		m |= modAlt
+	}
+	if mods&fyne.KeyModifierControl != 0 {
+		m |= modControl
+	}
+	if mods&fyne.KeyModifierShift != 0 {
+		m |= modShift
+	}
+	if mods&fyne.KeyModifierSuper != 0 {
+		m |= modWin
+	}
+	return m
+}
+
+// virtualKey 把 parseHotkey 接受的按键转换为虚拟键码
+func virtualKey(key fyne.KeyName) uintptr {
+	if key == fyne.KeySpace {
+		return 0x20
+	}
+	if n := functionKeyNumber(string(key)); n > 0 {
+		return uintptr(0x70 + n - 1) // VK_F1
+	}
+	return uintptr(key[0]) // 字母和数字的虚拟键码与 ASCII 大写字符相同
+}
@@ -0,0 +1,514 @@
+package locallauncher
+
+import (
+	"bufio"
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 图标提取只使用标准库：Windows 程序的 PE 资源、ICO、macOS 的 icns 和 Linux 的图标主题。
+// 结果统一转换为 PNG (SVG 保持原样)，Fyne 可以直接显示。
+
+var errNoIcon = errors.New("没有找到图标")
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+// iconImage 是提取到的图标数据，ext 为 ".png" 或 ".svg"
+type iconImage struct {
+	data []byte
+	ext  string
+}
+
+// normalizeIcon 把下载或提取到的图片数据转换为 Fyne 可以显示的格式
+func normalizeIcon(data []byte) (iconImage, error) {
+	switch {
+	case bytes.HasPrefix(data, pngMagic):
+		return iconImage{data, ".png"}, nil
+	case len(data) >= 6 && binary.LittleEndian.Uint16(data[0:]) == 0 && binary.LittleEndian.Uint16(data[2:]) == 1:
+		return icoToPNG(data)
+	case isSVG(data):
+		return iconImage{data, ".svg"}, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return iconImage{}, fmt.Errorf("无法识别的图标格式: %v", err)
+	}
+	return encodePNG(img)
+}
+
+func isSVG(data []byte) bool {
+	head := strings.ToLower(string(data[:min(len(data), 512)]))
+	return strings.Contains(head, "<svg")
+}
+
+func encodePNG(img image.Image) (iconImage, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return iconImage{}, err
+	}
+	return iconImage{buf.Bytes(), ".png"}, nil
+}
+
+// --- ICO ---
+
+// iconEntry 是 ICO 目录或 PE 图标组中的一项
+type iconEntry struct {
+	width, bitCount int
+	id              int // PE 图标组中 RT_ICON 资源的 ID
+	offset, size    int // ICO 文件中的位置
+}
+
+// better 判断 a 是否比 b 更适合：尺寸越大越好，尺寸相同时颜色位数越高越好
+func (a iconEntry) better(b iconEntry) bool {
+	if a.width != b.width {
+		return a.width > b.width
+	}
+	return a.bitCount > b.bitCount
+}
+
+// parseIconDir 解析 ICO 文件或 PE 图标组的目录。ICO 每项 16 字节，以数据偏移结尾；图标组每项 14 字节，以资源 ID 结尾。
+func parseIconDir(data []byte, group bool) ([]iconEntry, error) {
+	if len(data) < 6 {
+		return nil, errNoIcon
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	size := 16
+	if group {
+		size = 14
+	}
+	var entries []iconEntry
+	for i := 0; i < count; i++ {
+		if 6+(i+1)*size > len(data) {
+			return nil, errors.New("图标目录不完整")
+		}
+		b := data[6+i*size:]
+		e := iconEntry{width: int(b[0]), bitCount: int(binary.LittleEndian.Uint16(b[6:]))}
+		if e.width == 0 {
+			e.width = 256
+		}
+		if group {
+			e.id = int(binary.LittleEndian.Uint16(b[12:]))
+		} else {
+			e.size = int(binary.LittleEndian.Uint32(b[8:]))
+			e.offset = int(binary.LittleEndian.Uint32(b[12:]))
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, errNoIcon
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].better(entries[j]) })
+	return entries, nil
+}
+
+// icoToPNG 取出 ICO 文件中最大的一幅图像
+func icoToPNG(data []byte) (iconImage, error) {
+	entries, err := parseIconDir(data, false)
+	if err != nil {
+		return iconImage{}, err
+	}
+	for _, e := range entries {
+		if e.offset < 0 || e.size <= 0 || e.offset+e.size > len(data) {
+			continue
+		}
+		if img, err := decodeIconImage(data[e.offset : e.offset+e.size]); err == nil {
+			return img, nil
+		}
+	}
+	return iconImage{}, errNoIcon
+}
+
+// decodeIconImage 解码 ICO 中的单幅图像，可能是 PNG 或不带文件头的 BMP (DIB)
+func decodeIconImage(data []byte) (iconImage, error) {
+	if bytes.HasPrefix(data, pngMagic) {
+		return iconImage{data, ".png"}, nil
+	}
+	img, err := decodeDIB(data)
+	if err != nil {
+		return iconImage{}, err
+	}
+	return encodePNG(img)
+}
+
+// decodeDIB 解码图标中的 DIB：高度是图像和透明掩码之和，支持 32 位 (带 alpha)、24 位和 1/4/8 位调色板图像
+func decodeDIB(data []byte) (image.Image, error) {
+	if len(data) < 40 {
+		return nil, errors.New("DIB 数据太短")
+	}
+	le := binary.LittleEndian
+	headerSize := int(le.Uint32(data[0:]))
+	w := int(int32(le.Uint32(data[4:])))
+	h := int(int32(le.Uint32(data[8:]))) / 2
+	bpp := int(le.Uint16(data[14:]))
+	if w <= 0 || h <= 0 || w > 1024 || h > 1024 || headerSize < 40 || headerSize > len(data) {
+		return nil, errors.New("不支持的 DIB 尺寸")
+	}
+	switch bpp {
+	case 1, 4, 8, 24, 32:
+	default:
+		return nil, fmt.Errorf("不支持 %d 位的 DIB", bpp)
+	}
+	var palette []color.RGBA
+	pos := headerSize
+	if bpp <= 8 {
+		n := int(le.Uint32(data[32:]))
+		if n == 0 {
+			n = 1 << bpp
+		}
+		for i := 0; i < n && pos+4 <= len(data); i++ {
+			palette = append(palette, color.RGBA{data[pos+2], data[pos+1], data[pos], 255})
+			pos += 4
+		}
+	}
+	stride := (w*bpp + 31) / 32 * 4
+	maskStride := (w + 31) / 32 * 4
+	if pos+stride*h > len(data) {
+		return nil, errors.New("DIB 数据不完整")
+	}
+	maskStart := pos + stride*h
+	hasMask := maskStart+maskStride*h <= len(data)
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	anyAlpha := false
+	for y := 0; y < h; y++ {
+		row := data[pos+(h-1-y)*stride:] // 自下而上存储
+		for x := 0; x < w; x++ {
+			var c color.NRGBA
+			switch bpp {
+			case 32:
+				c = color.NRGBA{row[x*4+2], row[x*4+1], row[x*4], row[x*4+3]}
+				anyAlpha = anyAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{row[x*3+2], row[x*3+1], row[x*3], 255}
+			default:
+				bit := x * bpp
+				idx := int(row[bit/8]>>(8-bpp-bit%8)) & (1<<bpp - 1)
+				if idx < len(palette) {
+					p := palette[idx]
+					c = color.NRGBA{p.R, p.G, p.B, 255}
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	// 没有 alpha 通道时用掩码决定透明区域
+	if hasMask && (bpp != 32 || !anyAlpha) {
+		for y := 0; y < h; y++ {
+			row := data[maskStart+(h-1-y)*maskStride:]
+			for x := 0; x < w; x++ {
+				c := img.NRGBAAt(x, y)
+				c.A = 255
+				if row[x/8]&(0x80>>(x%8)) != 0 {
+					c.A = 0
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
+
+// --- Windows PE ---
+
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+)
+
+// extractPEIcon 读取 .exe/.dll 资源中的第一个图标组，取其中最大的图标
+func extractPEIcon(path string) (iconImage, error) {
+	f, err := pe.Open(path)
+	if err != nil {
+		return iconImage{}, err
+	}
+	defer f.Close()
+
+	var rva uint32
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if len(oh.DataDirectory) > pe.IMAGE_DIRECTORY_ENTRY_RESOURCE {
+			rva = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE].VirtualAddress
+		}
+	case *pe.OptionalHeader64:
+		if len(oh.DataDirectory) > pe.IMAGE_DIRECTORY_ENTRY_RESOURCE {
+			rva = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE].VirtualAddress
+		}
+	}
+	var section *pe.Section
+	for _, s := range f.Sections {
+		if rva >= s.VirtualAddress && rva < s.VirtualAddress+max(s.VirtualSize, s.Size) {
+			section = s
+		}
+	}
+	if rva == 0 || section == nil {
+		return iconImage{}, errNoIcon
+	}
+	data, err := section.Data()
+	if err != nil {
+		return iconImage{}, err
+	}
+	r := &peResources{data: data, base: section.VirtualAddress, root: int(rva - section.VirtualAddress)}
+
+	groups := r.resources(rtGroupIcon)
+	if len(groups) == 0 {
+		return iconImage{}, errNoIcon
+	}
+	icons := make(map[int][]byte)
+	for _, res := range r.resources(rtIcon) {
+		icons[res.id] = res.data
+	}
+	entries, err := parseIconDir(groups[0].data, true)
+	if err != nil {
+		return iconImage{}, err
+	}
+	for _, e := range entries {
+		if data, ok := icons[e.id]; ok {
+			if img, err := decodeIconImage(data); err == nil {
+				return img, nil
+			}
+		}
+	}
+	return iconImage{}, errNoIcon
+}
+
+// peResources 解析 .rsrc 节中的三层资源目录：类型 -> 名称/ID -> 语言
+type peResources struct {
+	data []byte
+	base uint32 // 节的虚拟地址，资源数据项中的偏移是 RVA
+	root int    // 资源根目录在节中的偏移
+}
+
+type peResource struct {
+	id   int
+	data []byte
+}
+
+// entries 返回目录 (相对根目录的偏移 off) 的所有项：ID 和指向的偏移，以及是否为子目录
+func (r *peResources) entries(off int) (ids []int, targets []int, dirs []bool) {
+	p := r.root + off
+	if p < 0 || p+16 > len(r.data) {
+		return
+	}
+	le := binary.LittleEndian
+	n := int(le.Uint16(r.data[p+12:])) + int(le.Uint16(r.data[p+14:]))
+	for i := 0; i < n; i++ {
+		e := p + 16 + i*8
+		if e+8 > len(r.data) {
+			break
+		}
+		ids = append(ids, int(le.Uint32(r.data[e:])&0x7fffffff))
+		target := le.Uint32(r.data[e+4:])
+		targets = append(targets, int(target&0x7fffffff))
+		dirs = append(dirs, target&0x80000000 != 0)
+	}
+	return
+}
+
+// resources 返回某一类型的所有资源，每个名称只取第一种语言
+func (r *peResources) resources(typ int) []peResource {
+	var out []peResource
+	ids, targets, dirs := r.entries(0)
+	for i, id := range ids {
+		if id != typ || !dirs[i] {
+			continue
+		}
+		names, nameTargets, nameDirs := r.entries(targets[i])
+		for j, name := range names {
+			off := nameTargets[j]
+			if nameDirs[j] {
+				_, langTargets, langDirs := r.entries(off)
+				if len(langTargets) == 0 || langDirs[0] {
+					continue
+				}
+				off = langTargets[0]
+			}
+			if data := r.dataEntry(off); data != nil {
+				out = append(out, peResource{id: name, data: data})
+			}
+		}
+	}
+	return out
+}
+
+func (r *peResources) dataEntry(off int) []byte {
+	p := r.root + off
+	if p < 0 || p+16 > len(r.data) {
+		return nil
+	}
+	rva := binary.LittleEndian.Uint32(r.data[p:])
+	size := int(binary.LittleEndian.Uint32(r.data[p+4:]))
+	start := int(rva) - int(r.base)
+	if start < 0 || size <= 0 || start+size > len(r.data) {
+		return nil
+	}
+	return r.data[start : start+size]
+}
+
+// --- macOS ---
+
+var bundleIconRe = regexp.MustCompile(`<key>CFBundleIconFile</key>\s*<string>([^<]+)</string>`)
+
+// extractAppBundleIcon 读取 .app 包 Info.plist 中的 CFBundleIconFile，并从 icns 中取出最大的 PNG
+func extractAppBundleIcon(bundle string) (iconImage, error) {
+	plist, err := os.ReadFile(filepath.Join(bundle, "Contents", "Info.plist"))
+	if err != nil {
+		return iconImage{}, err
+	}
+	m := bundleIconRe.FindSubmatch(plist)
+	if m == nil {
+		return iconImage{}, errNoIcon
+	}
+	name := string(m[1])
+	if filepath.Ext(name) == "" {
+		name += ".icns"
+	}
+	data, err := os.ReadFile(filepath.Join(bundle, "Contents", "Resources", name))
+	if err != nil {
+		return iconImage{}, err
+	}
+	return icnsToPNG(data)
+}
+
+// icnsToPNG 取出 icns 文件中尺寸最大的 PNG 图像 (较新的 icns 中大尺寸图像都是 PNG)
+func icnsToPNG(data []byte) (iconImage, error) {
+	if len(data) < 8 || string(data[:4]) != "icns" {
+		return iconImage{}, errors.New("不是 icns 文件")
+	}
+	var best []byte
+	bestWidth := 0
+	for p := 8; p+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[p+4:]))
+		if size < 8 || p+size > len(data) {
+			break
+		}
+		chunk := data[p+8 : p+size]
+		if bytes.HasPrefix(chunk, pngMagic) {
+			if cfg, err := png.DecodeConfig(bytes.NewReader(chunk)); err == nil && cfg.Width > bestWidth {
+				best, bestWidth = chunk, cfg.Width
+			}
+		}
+		p += size
+	}
+	if best == nil {
+		return iconImage{}, errNoIcon
+	}
+	return iconImage{best, ".png"}, nil
+}
+
+// --- Linux ---
+
+// desktopIconName 读取 .desktop 文件 [Desktop Entry] 中的 Icon=
+func desktopIconName(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	inEntry := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "Icon="); ok && inEntry {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", errNoIcon
+}
+
+// iconThemeDirs 是查找图标主题的目录，按优先级排列
+func iconThemeDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".local", "share", "icons"), filepath.Join(home, ".icons"))
+	}
+	return append(dirs, "/usr/local/share/icons", "/usr/share/icons")
+}
+
+// resolveThemeIcon 在 hicolor 图标主题和 pixmaps 中查找图标名称，优先选择尺寸最大的 PNG，其次是 SVG
+func resolveThemeIcon(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		if _, err := os.Stat(name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	var best string
+	bestSize := -1
+	for _, dir := range iconThemeDirs() {
+		matches, _ := filepath.Glob(filepath.Join(dir, "hicolor", "*", "apps", name+".png"))
+		for _, m := range matches {
+			sizeDir := filepath.Base(filepath.Dir(filepath.Dir(m))) // 例如 "256x256"
+			size, _ := strconv.Atoi(strings.Split(sizeDir, "x")[0])
+			if size > bestSize {
+				best, bestSize = m, size
+			}
+		}
+		if best != "" {
+			return best, nil
+		}
+		if svg := filepath.Join(dir, "hicolor", "scalable", "apps", name+".svg"); fileExists(svg) {
+			return svg, nil
+		}
+	}
+	for _, ext := range []string{".png", ".svg"} {
+		if p := filepath.Join("/usr/share/pixmaps", name+ext); fileExists(p) {
+			return p, nil
+		}
+	}
+	return "", errNoIcon
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// extractLocalIcon 按目标的类型提取图标：.exe/.dll 的资源、.app 包的 icns、.desktop 的 Icon=，
+// 以及与 Linux 程序同名的主题图标
+func extractLocalIcon(path string) (iconImage, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".exe", ".dll":
+		return extractPEIcon(path)
+	case ".app":
+		return extractAppBundleIcon(path)
+	case ".ico", ".png", ".svg", ".jpg", ".jpeg":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return iconImage{}, err
+		}
+		return normalizeIcon(data)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if strings.EqualFold(filepath.Ext(path), ".desktop") {
+		var err error
+		if name, err = desktopIconName(path); err != nil {
+			return iconImage{}, err
+		}
+	}
+	iconPath, err := resolveThemeIcon(name)
+	if err != nil {
+		return iconImage{}, err
+	}
+	data, err := os.ReadFile(iconPath)
+	if err != nil {
+		return iconImage{}, err
+	}
+	return normalizeIcon(data)
+}
@@ -0,0 +1,484 @@
+package locallauncher
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPNG 生成一幅 w x w 的纯色 PNG
+func testPNG(t *testing.T, w int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, w))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testDIB 生成图标中使用的 DIB：pixels 按行自上而下给出，每个像素占 bpp/8 字节 (1/4/8 位时为调色板索引)，
+// mask 为 nil 时不带透明掩码
+func testDIB(w, h, bpp int, palette []color.RGBA, pixels [][]byte, mask [][]bool) []byte {
+	le := binary.LittleEndian
+	header := make([]byte, 40)
+	le.PutUint32(header[0:], 40)
+	le.PutUint32(header[4:], uint32(w))
+	le.PutUint32(header[8:], uint32(h*2))
+	le.PutUint16(header[12:], 1)
+	le.PutUint16(header[14:], uint16(bpp))
+	le.PutUint32(header[32:], uint32(len(palette)))
+	buf := bytes.NewBuffer(header)
+	for _, c := range palette {
+		buf.Write([]byte{c.B, c.G, c.R, 0})
+	}
+	stride := (w*bpp + 31) / 32 * 4
+	for y := h - 1; y >= 0; y-- {
+		row := make([]byte, stride)
+		if bpp >= 8 {
+			copy(row, pixels[y])
+		} else {
+			for x, idx := range pixels[y] {
+				bit := x * bpp
+				row[bit/8] |= idx << (8 - bpp - bit%8)
+			}
+		}
+		buf.Write(row)
+	}
+	if mask != nil {
+		maskStride := (w + 31) / 32 * 4
+		for y := h - 1; y >= 0; y-- {
+			row := make([]byte, maskStride)
+			for x, transparent := range mask[y] {
+				if transparent {
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+			buf.Write(row)
+		}
+	}
+	return buf.Bytes()
+}
+
+// testICO 把若干幅图像打包成 ICO 文件，widths 是目录中记录的宽度
+func testICO(widths []int, images ...[]byte) []byte {
+	le := binary.LittleEndian
+	head := make([]byte, 6+16*len(images))
+	le.PutUint16(head[2:], 1)
+	le.PutUint16(head[4:], uint16(len(images)))
+	offset := len(head)
+	for i, img := range images {
+		e := head[6+16*i:]
+		e[0] = byte(widths[i])
+		le.PutUint16(e[6:], 32)
+		le.PutUint32(e[8:], uint32(len(img)))
+		le.PutUint32(e[12:], uint32(offset))
+		offset += len(img)
+	}
+	return append(head, bytes.Join(images, nil)...)
+}
+
+func TestParseIconDir(t *testing.T) {
+	le := binary.LittleEndian
+	// dir 生成目录，每项给出宽度、颜色位数和 (图标组中的) 资源 ID
+	dir := func(group bool, items ...[3]int) []byte {
+		size := 16
+		if group {
+			size = 14
+		}
+		data := make([]byte, 6+size*len(items))
+		le.PutUint16(data[2:], 1)
+		le.PutUint16(data[4:], uint16(len(items)))
+		for i, it := range items {
+			e := data[6+size*i:]
+			e[0] = byte(it[0])
+			le.PutUint16(e[6:], uint16(it[1]))
+			if group {
+				le.PutUint16(e[12:], uint16(it[2]))
+			} else {
+				le.PutUint32(e[8:], 100)
+				le.PutUint32(e[12:], uint32(it[2]))
+			}
+		}
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		group   bool
+		want    []iconEntry
+		wantErr bool
+	}{
+		{name: "太短", data: []byte{0, 0, 1, 0}, wantErr: true},
+		{name: "没有图像", data: dir(false), wantErr: true},
+		{name: "目录不完整", data: dir(false, [3]int{16, 32, 0})[:20], wantErr: true},
+		{
+			name: "ICO 按尺寸和颜色位数排序",
+			data: dir(false, [3]int{16, 32, 10}, [3]int{32, 8, 20}, [3]int{32, 32, 30}),
+			want: []iconEntry{
+				{width: 32, bitCount: 32, offset: 30, size: 100},
+				{width: 32, bitCount: 8, offset: 20, size: 100},
+				{width: 16, bitCount: 32, offset: 10, size: 100},
+			},
+		},
+		{
+			name: "宽度 0 表示 256",
+			data: dir(false, [3]int{48, 32, 10}, [3]int{0, 32, 20}),
+			want: []iconEntry{
+				{width: 256, bitCount: 32, offset: 20, size: 100},
+				{width: 48, bitCount: 32, offset: 10, size: 100},
+			},
+		},
+		{
+			name:  "图标组使用资源 ID",
+			data:  dir(true, [3]int{16, 32, 7}, [3]int{64, 32, 9}),
+			group: true,
+			want:  []iconEntry{{width: 64, bitCount: 32, id: 9}, {width: 16, bitCount: 32, id: 7}},
+		},
+		{
+			name:    "图标组按 ICO 的项长度读取时不完整",
+			data:    dir(true, [3]int{16, 32, 7}, [3]int{64, 32, 9}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIconDir(tt.data, tt.group)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseIconDir() = %v, 期望出错", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIconDir() 出错: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseIconDir() = %v, 期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("第 %d 项 = %+v, 期望 %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeDIB(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]color.NRGBA // 按行自上而下
+		wantErr bool
+	}{
+		{
+			name: "32 位带 alpha 时忽略掩码",
+			data: testDIB(2, 1, 32, nil, [][]byte{{0, 0, 255, 128, 255, 0, 0, 255}}, [][]bool{{true, true}}),
+			want: [][]color.NRGBA{{{255, 0, 0, 128}, {0, 0, 255, 255}}},
+		},
+		{
+			name: "32 位 alpha 全为 0 时使用掩码",
+			data: testDIB(2, 1, 32, nil, [][]byte{{0, 0, 255, 0, 255, 0, 0, 0}}, [][]bool{{false, true}}),
+			want: [][]color.NRGBA{{{255, 0, 0, 255}, {0, 0, 255, 0}}},
+		},
+		{
+			name: "24 位自下而上存储",
+			data: testDIB(1, 2, 24, nil, [][]byte{{0, 0, 255}, {255, 0, 0}}, nil),
+			want: [][]color.NRGBA{{{255, 0, 0, 255}}, {{0, 0, 255, 255}}},
+		},
+		{
+			name: "1 位调色板和掩码",
+			data: testDIB(3, 1, 1, []color.RGBA{red, blue}, [][]byte{{0, 1, 1}}, [][]bool{{false, false, true}}),
+			want: [][]color.NRGBA{{{255, 0, 0, 255}, {0, 0, 255, 255}, {0, 0, 255, 0}}},
+		},
+		{
+			name: "4 位调色板",
+			data: testDIB(2, 1, 4, []color.RGBA{red, blue}, [][]byte{{1, 0}}, nil),
+			want: [][]color.NRGBA{{{0, 0, 255, 255}, {255, 0, 0, 255}}},
+		},
+		{
+			name: "8 位调色板，超出调色板的索引为透明",
+			data: testDIB(2, 1, 8, []color.RGBA{red}, [][]byte{{0, 5}}, nil),
+			want: [][]color.NRGBA{{{255, 0, 0, 255}, {}}},
+		},
+		{name: "太短", data: make([]byte, 39), wantErr: true},
+		{name: "不支持的位数", data: testDIB(1, 1, 16, nil, [][]byte{{0, 0}}, nil), wantErr: true},
+		{name: "尺寸太大", data: testDIB(2048, 1, 32, nil, [][]byte{make([]byte, 2048*4)}, nil), wantErr: true},
+		{name: "像素数据不完整", data: testDIB(4, 4, 32, nil, [][]byte{{}, {}, {}, {}}, nil)[:60], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeDIB(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decodeDIB() 期望出错")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeDIB() 出错: %v", err)
+			}
+			b := img.Bounds()
+			if b.Dy() != len(tt.want) || b.Dx() != len(tt.want[0]) {
+				t.Fatalf("尺寸 = %v, 期望 %dx%d", b.Size(), len(tt.want[0]), len(tt.want))
+			}
+			for y, row := range tt.want {
+				for x, want := range row {
+					if got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA); got != want {
+						t.Errorf("(%d,%d) = %v, 期望 %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIcoToPNG(t *testing.T) {
+	small := testDIB(1, 1, 32, nil, [][]byte{{0, 0, 255, 255}}, nil)
+	large := testPNG(t, 4)
+
+	img, err := normalizeIcon(testICO([]int{1, 4}, small, large))
+	if err != nil {
+		t.Fatalf("normalizeIcon() 出错: %v", err)
+	}
+	if img.ext != ".png" || !bytes.Equal(img.data, large) {
+		t.Errorf("没有选择最大的图像")
+	}
+
+	// 内嵌的 PNG 损坏时 (这里偏移超出文件) 跳过，退回到 DIB
+	broken := testICO([]int{1, 4}, small, large)
+	binary.LittleEndian.PutUint32(broken[6+16+12:], uint32(len(broken)))
+	img, err = icoToPNG(broken)
+	if err != nil {
+		t.Fatalf("icoToPNG() 出错: %v", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(img.data))
+	if err != nil || cfg.Width != 1 {
+		t.Errorf("期望退回到 1x1 的 DIB, 得到 %+v (%v)", cfg, err)
+	}
+
+	for n := 0; n < len(broken); n += 7 {
+		if _, err := icoToPNG(broken[:n]); err == nil && n < 6+32 {
+			t.Errorf("截断到 %d 字节时期望出错", n)
+		}
+	}
+}
+
+func TestIcnsToPNG(t *testing.T) {
+	chunk := func(typ string, data []byte) []byte {
+		head := make([]byte, 8)
+		copy(head, typ)
+		binary.BigEndian.PutUint32(head[4:], uint32(8+len(data)))
+		return append(head, data...)
+	}
+	icns := func(chunks ...[]byte) []byte {
+		body := bytes.Join(chunks, nil)
+		head := []byte("icns\x00\x00\x00\x00")
+		binary.BigEndian.PutUint32(head[4:], uint32(8+len(body)))
+		return append(head, body...)
+	}
+	small, large := testPNG(t, 2), testPNG(t, 8)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "选择最大的 PNG", data: icns(chunk("ic07", small), chunk("is32", []byte("rle")), chunk("ic08", large)), want: large},
+		{name: "不是 icns", data: []byte("ICNS\x00\x00\x00\x08"), wantErr: true},
+		{name: "太短", data: []byte("icns"), wantErr: true},
+		{name: "没有 PNG", data: icns(chunk("is32", []byte("rle"))), wantErr: true},
+		{name: "截断的块被忽略", data: icns(chunk("ic07", small), chunk("ic08", large))[:8+8+len(small)+20], want: small},
+		{name: "块长度小于头部", data: icns([]byte("ic07\x00\x00\x00\x04"), chunk("ic08", large)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := icnsToPNG(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("icnsToPNG() 期望出错")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("icnsToPNG() 出错: %v", err)
+			}
+			if img.ext != ".png" || !bytes.Equal(img.data, tt.want) {
+				t.Errorf("icnsToPNG() 返回了错误的图像")
+			}
+		})
+	}
+}
+
+// testResourceSection 生成 .rsrc 节：一个 RT_ICON (ID 1) 和一个引用它的 RT_GROUP_ICON (ID 1)。
+// base 是节的虚拟地址，nameToData 为 true 时图标组的名称项直接指向数据项，不经过语言目录。
+func testResourceSection(base uint32, icon []byte, nameToData bool) []byte {
+	le := binary.LittleEndian
+	const (
+		iconDir, iconLangDir   = 32, 56
+		groupDir, groupLangDir = 80, 104
+		iconData, groupData    = 128, 144
+		payload                = 160
+	)
+	group := make([]byte, 6+14)
+	le.PutUint16(group[2:], 1)
+	le.PutUint16(group[4:], 1)
+	group[6] = 32
+	le.PutUint16(group[6+6:], 32)
+	le.PutUint16(group[6+12:], 1)
+
+	data := make([]byte, payload)
+	data = append(data, icon...)
+	data = append(data, group...)
+
+	directory := func(off int, entries ...[2]uint32) {
+		le.PutUint16(data[off+14:], uint16(len(entries)))
+		for i, e := range entries {
+			le.PutUint32(data[off+16+i*8:], e[0])
+			le.PutUint32(data[off+16+i*8+4:], e[1])
+		}
+	}
+	const subdir = 0x80000000
+	directory(0, [2]uint32{rtIcon, subdir | iconDir}, [2]uint32{rtGroupIcon, subdir | groupDir})
+	directory(iconDir, [2]uint32{1, subdir | iconLangDir})
+	directory(iconLangDir, [2]uint32{0x409, iconData})
+	if nameToData {
+		directory(groupDir, [2]uint32{1, groupData})
+	} else {
+		directory(groupDir, [2]uint32{1, subdir | groupLangDir})
+		directory(groupLangDir, [2]uint32{0x409, groupData})
+	}
+	le.PutUint32(data[iconData:], base+payload)
+	le.PutUint32(data[iconData+4:], uint32(len(icon)))
+	le.PutUint32(data[groupData:], base+payload+uint32(len(icon)))
+	le.PutUint32(data[groupData+4:], uint32(len(group)))
+	return data
+}
+
+func TestPEResources(t *testing.T) {
+	const base = 0x1000
+	icon := testPNG(t, 4)
+	for _, nameToData := range []bool{false, true} {
+		r := &peResources{data: testResourceSection(base, icon, nameToData), base: base}
+		icons := r.resources(rtIcon)
+		if len(icons) != 1 || icons[0].id != 1 || !bytes.Equal(icons[0].data, icon) {
+			t.Fatalf("RT_ICON = %v", icons)
+		}
+		groups := r.resources(rtGroupIcon)
+		if len(groups) != 1 {
+			t.Fatalf("RT_GROUP_ICON = %v (nameToData=%v)", groups, nameToData)
+		}
+		entries, err := parseIconDir(groups[0].data, true)
+		if err != nil || len(entries) != 1 || entries[0].id != 1 {
+			t.Errorf("图标组 = %v, %v", entries, err)
+		}
+		if res := r.resources(24); len(res) != 0 { // RT_MANIFEST
+			t.Errorf("不存在的类型返回了 %v", res)
+		}
+	}
+
+	// 截断或偏移错误的节不能越界，只是找不到资源
+	full := testResourceSection(base, icon, false)
+	for n := 0; n < len(full); n++ {
+		r := &peResources{data: full[:n], base: base}
+		r.resources(rtIcon)
+		r.resources(rtGroupIcon)
+	}
+	for _, r := range []*peResources{
+		{data: full, base: base + 0x100}, // 数据项的 RVA 落在节之前
+		{data: full, base: base, root: len(full) - 8},
+		{data: full, base: base, root: -1},
+	} {
+		if res := r.resources(rtIcon); len(res) != 0 {
+			t.Errorf("错误的节返回了 %v", res)
+		}
+	}
+}
+
+// testPEFile 生成只有一个 .rsrc 节的最小 32 位 PE 文件
+func testPEFile(t *testing.T, rsrc []byte, rva uint32) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	dos := make([]byte, 64)
+	copy(dos, "MZ")
+	le.PutUint32(dos[0x3c:], 64)
+	buf.Write(dos)
+	buf.WriteString("PE\x00\x00")
+
+	opt := pe.OptionalHeader32{Magic: 0x10b, SectionAlignment: 0x1000, FileAlignment: 0x200, NumberOfRvaAndSizes: 16}
+	opt.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE] = pe.DataDirectory{VirtualAddress: rva, Size: uint32(len(rsrc))}
+	section := pe.SectionHeader32{
+		VirtualSize:      uint32(len(rsrc)),
+		VirtualAddress:   rva,
+		SizeOfRawData:    uint32(len(rsrc)),
+		PointerToRawData: 0x200,
+	}
+	copy(section.Name[:], ".rsrc")
+	for _, v := range []any{
+		pe.FileHeader{Machine: pe.IMAGE_FILE_MACHINE_I386, NumberOfSections: 1, SizeOfOptionalHeader: uint16(binary.Size(opt))},
+		opt,
+		section,
+	} {
+		if err := binary.Write(&buf, le, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write(make([]byte, 0x200-buf.Len()))
+	buf.Write(rsrc)
+	return buf.Bytes()
+}
+
+func TestExtractPEIcon(t *testing.T) {
+	const rva = 0x2000
+	dir := t.TempDir()
+	icon := testDIB(2, 2, 32, nil, [][]byte{make([]byte, 8), make([]byte, 8)}, nil)
+
+	exe := filepath.Join(dir, "app.exe")
+	if err := os.WriteFile(exe, testPEFile(t, testResourceSection(rva, icon, false), rva), 0644); err != nil {
+		t.Fatal(err)
+	}
+	img, err := extractPEIcon(exe)
+	if err != nil {
+		t.Fatalf("extractPEIcon() 出错: %v", err)
+	}
+	if cfg, err := png.DecodeConfig(bytes.NewReader(img.data)); err != nil || cfg.Width != 2 {
+		t.Errorf("extractPEIcon() = %+v, %v", cfg, err)
+	}
+
+	// 资源目录中没有图标
+	empty := filepath.Join(dir, "empty.exe")
+	if err := os.WriteFile(empty, testPEFile(t, make([]byte, 16), rva), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := extractPEIcon(empty); !errors.Is(err, errNoIcon) {
+		t.Errorf("没有图标时 extractPEIcon() = %v, 期望 errNoIcon", err)
+	}
+
+	// 截断的 PE 文件只能返回错误
+	full := testPEFile(t, testResourceSection(rva, icon, false), rva)
+	for _, n := range []int{0, 2, 64, 100, 300, 0x200, 0x200 + 50} {
+		p := filepath.Join(dir, "truncated.exe")
+		if err := os.WriteFile(p, full[:n], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := extractPEIcon(p); err == nil {
+			t.Errorf("截断到 %d 字节时期望出错", n)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package locallauncher
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// 自动获取的图标缓存在 data/locallauncher_icons 中，文件名由条目的类型和路径决定，
+// 因此修改路径后会重新获取。用户手动选择的图标 (ToolConfig.Icon) 总是优先。
+
+const (
+	iconCacheDir    = "locallauncher_icons"
+	iconWorkers     = 4
+	faviconTimeout  = 10 * time.Second
+	maxFaviconBytes = 1 << 20
+)
+
+var (
+	// iconResources 缓存已加载的图标文件，避免每次刷新界面都读取磁盘
+	iconResources = map[string]fyne.Resource{}
+	// iconFailed 记录本次运行中获取失败的条目，自动获取时不再重试，刷新图标时会重试
+	iconFailed = map[string]bool{}
+)
+
+// iconCacheKey 是条目在图标缓存中的文件名 (不含扩展名)，不能自动获取图标的条目返回空字符串
+func iconCacheKey(conf ToolConfig) string {
+	switch conf.Type {
+	case AppTool, WebTool, FileTool:
+	default:
+		return ""
+	}
+	conf, err := conf.resolved()
+	if err != nil || conf.Path == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(string(conf.Type) + "\x00" + conf.Path))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedIconPath 返回条目已缓存的图标文件
+func cachedIconPath(conf ToolConfig) (string, bool) {
+	key := iconCacheKey(conf)
+	if key == "" {
+		return "", false
+	}
+	for _, ext := range []string{".png", ".svg"} {
+		p := filepath.Join(configDir, iconCacheDir, key+ext)
+		if fileExists(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// loadIcon 从磁盘加载图标文件，结果会被缓存
+func loadIcon(path string) (fyne.Resource, error) {
+	if res, ok := iconResources[path]; ok {
+		return res, nil
+	}
+	res, err := fyne.LoadResourceFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	iconResources[path] = res
+	return res, nil
+}
+
+// fetchIcon 获取条目的图标：网站下载 favicon，本地程序和文件从目标中提取
+func fetchIcon(ctx context.Context, client *http.Client, conf ToolConfig) (iconImage, error) {
+	conf, err := conf.resolved()
+	if err != nil {
+		return iconImage{}, err
+	}
+	if conf.Type == WebTool {
+		return fetchFavicon(ctx, client, conf.Path)
+	}
+	return extractLocalIcon(conf.Path)
+}
+
+// saveCachedIcon 把图标写入缓存，同时删除另一种扩展名的旧文件
+func saveCachedIcon(conf ToolConfig, img iconImage) (string, error) {
+	dir := filepath.Join(configDir, iconCacheDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	key := iconCacheKey(conf)
+	for _, ext := range []string{".png", ".svg"} {
+		os.Remove(filepath.Join(dir, key+ext))
+	}
+	path := filepath.Join(dir, key+img.ext)
+	return path, os.WriteFile(path, img.data, 0644)
+}
+
+var (
+	linkTagRe = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attrRe    = regexp.MustCompile(`(?is)\b(rel|href)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// faviconCandidates 从页面的 <link rel="icon"> 中找出图标地址，apple-touch-icon 通常尺寸更大，排在前面。
+// 最后总是加上网站根目录的 /favicon.ico。
+func faviconCandidates(page *url.URL, html string) []string {
+	var touch, icons []string
+	for _, tag := range linkTagRe.FindAllString(html, -1) {
+		var rel, href string
+		for _, m := range attrRe.FindAllStringSubmatch(tag, -1) {
+			value := m[2] + m[3] + m[4]
+			if strings.EqualFold(m[1], "rel") {
+				rel = strings.ToLower(value)
+			} else {
+				href = value
+			}
+		}
+		if href == "" || !strings.Contains(rel, "icon") || strings.Contains(rel, "mask-icon") {
+			continue
+		}
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		abs := page.ResolveReference(ref).String()
+		if strings.Contains(rel, "apple-touch-icon") {
+			touch = append(touch, abs)
+		} else {
+			icons = append(icons, abs)
+		}
+	}
+	root := &url.URL{Scheme: page.Scheme, Host: page.Host, Path: "/favicon.ico"}
+	return append(append(touch, icons...), root.String())
+}
+
+// fetchFavicon 下载网页，依次尝试其中声明的图标和 /favicon.ico，返回第一个能识别的图标。
+// 超过 maxFaviconBytes 的图标会被跳过，而不是截断后当作图标保存。
+func fetchFavicon(ctx context.Context, client *http.Client, pageURL string) (iconImage, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil || page.Host == "" {
+		return iconImage{}, fmt.Errorf("无效的网址: %s", pageURL)
+	}
+	html, _ := httpGet(ctx, client, pageURL) // 页面无法访问时仍然尝试 /favicon.ico
+	var lastErr error = errNoIcon
+	for _, candidate := range faviconCandidates(page, string(html)) {
+		data, err := httpGet(ctx, client, candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		img, err := normalizeIcon(data)
+		if err == nil {
+			return img, nil
+		}
+		lastErr = err
+	}
+	return iconImage{}, lastErr
+}
+
+func httpGet(ctx context.Context, client *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (yanshu-toolkit)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	// 多读一个字节以判断是否超出限制；超出时返回截断的内容和错误，网页只需要开头的 <head>
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconBytes+1))
+	if err == nil && len(data) > maxFaviconBytes {
+		return data[:maxFaviconBytes], fmt.Errorf("%s: 超过 %d 字节", u, maxFaviconBytes)
+	}
+	return data, err
+}
+
+// refreshIcons 在后台获取图标。force 为 false 时只获取还没有缓存、且本次运行中没有失败过的条目；
+// 为 true 时重新获取 indices 中所有条目 (为空时表示全部) 并报告结果。
+func (t *localLauncherTool) refreshIcons(indices []int, force bool) {
+	if indices == nil {
+		for i := range t.configs {
+			indices = append(indices, i)
+		}
+	}
+	var todo []ToolConfig
+	for _, i := range indices {
+		conf := t.configs[i]
+		key := iconCacheKey(conf)
+		if key == "" || conf.Icon != "" {
+			continue
+		}
+		if !force {
+			if _, ok := cachedIconPath(conf); ok || iconFailed[key] {
+				continue
+			}
+		}
+		todo = append(todo, conf)
+	}
+	if len(todo) == 0 {
+		if force {
+			dialog.ShowInformation("刷新图标", "没有可以自动获取图标的条目。\n(网站、本地软件和文件支持自动获取)", t.win)
+		}
+		return
+	}
+
+	go func() {
+		client := &http.Client{Timeout: faviconTimeout}
+		ctx := context.Background()
+		jobs := make(chan ToolConfig)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var failed []string
+		for range iconWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for conf := range jobs {
+					var path string
+					img, err := fetchIcon(ctx, client, conf)
+					if err == nil {
+						path, err = saveCachedIcon(conf, img)
+					}
+					key := iconCacheKey(conf)
+					if err != nil {
+						log.Printf("获取 %s 的图标失败: %v", conf.Name, err)
+						mu.Lock()
+						failed = append(failed, fmt.Sprintf("%s: %v", conf.Name, err))
+						mu.Unlock()
+					}
+					fyne.Do(func() {
+						iconFailed[key] = err != nil
+						delete(iconResources, path)
+					})
+				}
+			}()
+		}
+		for _, conf := range todo {
+			jobs <- conf
+		}
+		close(jobs)
+		wg.Wait()
+		fyne.Do(func() {
+			if activeTool == t {
+				t.refreshUI()
+			}
+			if force {
+				msg := fmt.Sprintf("已更新 %d 个图标。", len(todo)-len(failed))
+				if len(failed) > 0 {
+					msg += fmt.Sprintf("\n失败 %d 个:\n%s", len(failed), strings.Join(failed, "\n"))
+				}
+				dialog.ShowInformation("刷新图标", msg, t.win)
+			}
+		})
+	}()
+}
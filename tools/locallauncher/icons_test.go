@@ -0,0 +1,169 @@
+package locallauncher
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFaviconCandidates(t *testing.T) {
+	page, _ := url.Parse("https://example.com/docs/index.html")
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{
+			name: "没有声明图标",
+			html: "<html><head><title>x</title></head></html>",
+			want: []string{"https://example.com/favicon.ico"},
+		},
+		{
+			name: "apple-touch-icon 排在前面",
+			html: `<link rel="icon" href="/a.png"><link rel="apple-touch-icon" href="/touch.png">`,
+			want: []string{"https://example.com/touch.png", "https://example.com/a.png", "https://example.com/favicon.ico"},
+		},
+		{
+			name: "相对地址、单引号和不带引号的属性",
+			html: `<LINK HREF='img/b.ico' REL='Shortcut Icon'><link rel=icon href=//cdn.example.net/c.png>`,
+			want: []string{"https://example.com/docs/img/b.ico", "https://cdn.example.net/c.png", "https://example.com/favicon.ico"},
+		},
+		{
+			name: "忽略 mask-icon、样式表和没有 href 的项",
+			html: `<link rel="mask-icon" href="/m.svg"><link rel="stylesheet" href="/s.css"><link rel="icon">`,
+			want: []string{"https://example.com/favicon.ico"},
+		},
+		{
+			name: "属性跨行",
+			html: "<link\n  rel=\"icon\"\n  href=\"/multi.png\"\n>",
+			want: []string{"https://example.com/multi.png", "https://example.com/favicon.ico"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := faviconCandidates(page, tt.html); !slices.Equal(got, tt.want) {
+				t.Errorf("faviconCandidates() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchFavicon(t *testing.T) {
+	icon := testPNG(t, 4)
+	ico := testICO([]int{2}, testDIB(2, 2, 32, nil, [][]byte{make([]byte, 8), make([]byte, 8)}, nil))
+	oversized := append(append([]byte{}, icon...), make([]byte, maxFaviconBytes)...)
+
+	tests := []struct {
+		name    string
+		routes  map[string][]byte // 没有列出的路径返回 404
+		wantExt string
+		want    []byte
+		wantErr string
+	}{
+		{
+			name:    "页面中声明的图标",
+			routes:  map[string][]byte{"/": []byte(`<link rel="icon" href="/static/i.png">`), "/static/i.png": icon, "/favicon.ico": ico},
+			wantExt: ".png",
+			want:    icon,
+		},
+		{
+			name:    "没有声明时使用 /favicon.ico",
+			routes:  map[string][]byte{"/": []byte("<html></html>"), "/favicon.ico": ico},
+			wantExt: ".png",
+		},
+		{
+			name:    "页面无法访问时仍然尝试 /favicon.ico",
+			routes:  map[string][]byte{"/favicon.ico": ico},
+			wantExt: ".png",
+		},
+		{
+			name:    "声明的图标不存在时退回到 /favicon.ico",
+			routes:  map[string][]byte{"/": []byte(`<link rel="icon" href="/missing.png">`), "/favicon.ico": ico},
+			wantExt: ".png",
+		},
+		{
+			name:    "全部返回 404",
+			routes:  map[string][]byte{"/": []byte("<html></html>")},
+			wantErr: "404",
+		},
+		{
+			name:    "无法识别的图标",
+			routes:  map[string][]byte{"/": []byte("<html></html>"), "/favicon.ico": []byte("not an image")},
+			wantErr: "无法识别",
+		},
+		{
+			name:    "超过大小限制的图标被跳过",
+			routes:  map[string][]byte{"/": []byte(`<link rel="icon" href="/big.png">`), "/big.png": oversized, "/favicon.ico": ico},
+			wantExt: ".png",
+		},
+		{
+			name:    "只有超过大小限制的图标",
+			routes:  map[string][]byte{"/": []byte(`<link rel="icon" href="/big.png">`), "/big.png": oversized},
+			wantErr: "404", // 最后尝试的 /favicon.ico
+		},
+		{
+			name:    "超过大小限制的页面仍然读取开头的声明",
+			routes:  map[string][]byte{"/": append([]byte(`<link rel="icon" href="/i.png">`), make([]byte, maxFaviconBytes)...), "/i.png": icon},
+			wantExt: ".png",
+			want:    icon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := tt.routes[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			img, err := fetchFavicon(context.Background(), srv.Client(), srv.URL+"/")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchFavicon() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchFavicon() 出错: %v", err)
+			}
+			if img.ext != tt.wantExt {
+				t.Errorf("ext = %q, 期望 %q", img.ext, tt.wantExt)
+			}
+			if tt.want != nil && !bytes.Equal(img.data, tt.want) {
+				t.Errorf("返回的不是期望的图标")
+			}
+		})
+	}
+
+	if _, err := fetchFavicon(context.Background(), http.DefaultClient, "not a url"); err == nil {
+		t.Error("无效的网址期望出错")
+	}
+}
+
+func TestHTTPGetSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := maxFaviconBytes
+		if r.URL.Path == "/big" {
+			n++
+		}
+		w.Write(make([]byte, n))
+	}))
+	defer srv.Close()
+
+	if data, err := httpGet(context.Background(), srv.Client(), srv.URL+"/exact"); err != nil || len(data) != maxFaviconBytes {
+		t.Errorf("正好达到限制: %d 字节, %v", len(data), err)
+	}
+	data, err := httpGet(context.Background(), srv.Client(), srv.URL+"/big")
+	if err == nil || len(data) != maxFaviconBytes {
+		t.Errorf("超过限制: %d 字节, %v, 期望截断并出错", len(data), err)
+	}
+}
@@ -0,0 +1,177 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showImportMenu 在按钮下方弹出导入方式的菜单
+func (t *localLauncherTool) showImportMenu(btn fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("从文件导入 (.desktop / .lnk / 书签)...", t.importFromFile),
+		fyne.NewMenuItem("从文件夹导入所有 .desktop / .lnk...", t.importFromFolder),
+	)
+	widget.ShowPopUpMenuAtRelativePosition(menu, t.win.Canvas(), fyne.NewPos(0, btn.Size().Height), btn)
+}
+
+func (t *localLauncherTool) importFromFile() {
+	// 不设置过滤器：Chrome 的书签文件名为 Bookmarks，没有扩展名
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+		confs, err := parseImportFile(path)
+		if errors.Is(err, errSkipDesktop) {
+			err = fmt.Errorf("%s 被设置为不在菜单中显示 (NoDisplay/Hidden)", path)
+		}
+		if err != nil {
+			dialog.ShowError(err, t.win)
+			return
+		}
+		t.showImportPreview(confs, nil)
+	}, t.win)
+	d.Resize(fyne.NewSize(800, 600))
+	d.Show()
+}
+
+func (t *localLauncherTool) importFromFolder() {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		confs, errs := parseImportDir(uri.Path())
+		var problems []string
+		for _, err := range errs {
+			if !errors.Is(err, errSkipDesktop) {
+				problems = append(problems, err.Error())
+			}
+		}
+		t.showImportPreview(confs, problems)
+	}, t.win)
+}
+
+// importItem 是预览列表中的一行
+type importItem struct {
+	conf     ToolConfig
+	selected bool
+	exists   bool // 已有相同类型和路径的条目
+}
+
+// showImportPreview 列出解析出的条目供用户勾选，已经存在的条目默认不勾选
+func (t *localLauncherTool) showImportPreview(confs []ToolConfig, problems []string) {
+	if len(confs) == 0 {
+		msg := "没有找到可以导入的条目。"
+		if len(problems) > 0 {
+			msg += "\n\n" + strings.Join(problems, "\n")
+		}
+		dialog.ShowInformation("导入", msg, t.win)
+		return
+	}
+	existing := make(map[string]bool, len(t.configs))
+	for _, c := range t.configs {
+		existing[string(c.Type)+"\x00"+c.Path] = true
+	}
+	items := make([]*importItem, len(confs))
+	for i, c := range confs {
+		if c.Group == "" {
+			c.Group = defaultGroup
+		}
+		key := string(c.Type) + "\x00" + c.Path
+		items[i] = &importItem{conf: c, exists: existing[key], selected: !existing[key]}
+		existing[key] = true // 同一批中重复的条目只勾选第一个
+	}
+
+	summary := widget.NewLabel("")
+	updateSummary := func() {
+		n := 0
+		for _, it := range items {
+			if it.selected {
+				n++
+			}
+		}
+		summary.SetText(fmt.Sprintf("共 %d 个条目，已选择 %d 个", len(items), n))
+	}
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil,
+				container.NewVBox(widget.NewLabel(""), widget.NewLabel("")))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			it := items[id]
+			row := obj.(*fyne.Container)
+			labels := row.Objects[0].(*fyne.Container)
+			check := row.Objects[1].(*widget.Check)
+			check.OnChanged = nil
+			check.SetChecked(it.selected)
+			check.OnChanged = func(b bool) {
+				it.selected = b
+				updateSummary()
+			}
+			name := fmt.Sprintf("%s  [%s · %s]", it.conf.Name, toolTypeLabels[it.conf.Type], it.conf.Group)
+			if it.exists {
+				name += " (已存在)"
+			}
+			labels.Objects[0].(*widget.Label).SetText(name)
+			detail := labels.Objects[1].(*widget.Label)
+			detail.Truncation = fyne.TextTruncateEllipsis
+			detail.SetText(strings.TrimSpace(it.conf.Path + " " + it.conf.Args))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		items[id].selected = !items[id].selected
+		list.UnselectAll()
+		list.RefreshItem(id)
+		updateSummary()
+	}
+	setAll := func(b bool) {
+		for _, it := range items {
+			it.selected = b
+		}
+		list.Refresh()
+		updateSummary()
+	}
+	updateSummary()
+
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(
+		widget.NewButton("全选", func() { setAll(true) }),
+		widget.NewButton("全不选", func() { setAll(false) }),
+	), summary)
+	content := container.NewBorder(top, nil, nil, nil, list)
+	if len(problems) > 0 {
+		errs := widget.NewLabel(fmt.Sprintf("%d 个文件无法导入:\n%s", len(problems), strings.Join(problems, "\n")))
+		errs.Wrapping = fyne.TextWrapWord
+		content = container.NewBorder(top, container.NewVScroll(errs), nil, nil, list)
+	}
+
+	d := dialog.NewCustomConfirm("导入条目", "导入", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		added := 0
+		for _, it := range items {
+			if !it.selected {
+				continue
+			}
+			t.configs = append(t.configs, it.conf)
+			t.ensureGroup(it.conf.Group)
+			added++
+		}
+		if added == 0 {
+			return
+		}
+		t.saveConfig()
+		t.refreshUI()
+		t.refreshIcons(nil, false)
+	}, t.win)
+	d.Resize(fyne.NewSize(700, 560))
+	d.Show()
+}
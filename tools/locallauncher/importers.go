@@ -0,0 +1,447 @@
+package locallauncher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 导入器把其他程序的启动方式转换为 ToolConfig：Linux 的 .desktop 文件、Windows 的 .lnk 快捷方式、
+// 浏览器导出的 Netscape 格式书签 HTML 和 Chrome 的 Bookmarks JSON。
+
+const bookmarksGroup = "书签"
+
+// parseImportFile 根据扩展名 (或内容) 选择导入器
+func parseImportFile(path string) ([]ToolConfig, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".desktop":
+		conf, err := parseDesktopFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []ToolConfig{conf}, nil
+	case ".lnk":
+		conf, err := parseLnkFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []ToolConfig{conf}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseChromeBookmarks(data)
+	}
+	if bytes.Contains(bytes.ToUpper(data[:min(len(data), 1024)]), []byte("NETSCAPE-BOOKMARK-FILE")) || bytes.Contains(bytes.ToUpper(data), []byte("<DT><A ")) {
+		return parseBookmarksHTML(string(data)), nil
+	}
+	return nil, fmt.Errorf("无法识别 %s 的格式，支持 .desktop、.lnk、书签 HTML 和 Chrome Bookmarks 文件", filepath.Base(path))
+}
+
+// parseImportDir 导入文件夹中 (不递归子文件夹) 所有的 .desktop 和 .lnk 文件，无法解析的文件被跳过
+func parseImportDir(dir string) ([]ToolConfig, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var confs []ToolConfig
+	var errs []error
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || ext != ".desktop" && ext != ".lnk" {
+			continue
+		}
+		parsed, err := parseImportFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", e.Name(), err))
+			continue
+		}
+		confs = append(confs, parsed...)
+	}
+	return confs, errs
+}
+
+// --- .desktop ---
+
+var errSkipDesktop = errors.New("该条目被设置为不显示")
+
+// desktopCategoryGroups 把 freedesktop 的主分类映射为分组名称
+var desktopCategoryGroups = map[string]string{
+	"AudioVideo":  "影音",
+	"Audio":       "影音",
+	"Video":       "影音",
+	"Development": "开发",
+	"Education":   "教育",
+	"Game":        "游戏",
+	"Graphics":    "图形",
+	"Network":     "网络",
+	"Office":      "办公",
+	"Science":     "科学",
+	"Settings":    "设置",
+	"System":      "系统",
+	"Utility":     "工具",
+}
+
+// desktopFieldCodes 匹配 Exec= 中的 %f、%U 等占位符
+var desktopFieldCodes = regexp.MustCompile(`%[fFuUdDnNickvm]`)
+
+// parseDesktopFile 解析 [Desktop Entry]。Type=Application 成为本地软件，Type=Link 成为网站；
+// 名称优先使用简体中文的本地化名称，分组取第一个能识别的主分类。
+func parseDesktopFile(path string) (ToolConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ToolConfig{}, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	inEntry := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inEntry {
+			key = strings.TrimSpace(key)
+			if _, seen := values[key]; !seen {
+				values[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ToolConfig{}, err
+	}
+	if values["NoDisplay"] == "true" || values["Hidden"] == "true" {
+		return ToolConfig{}, errSkipDesktop
+	}
+
+	conf := ToolConfig{Name: values["Name"]}
+	for _, key := range []string{"Name[zh_CN]", "Name[zh]"} {
+		if values[key] != "" {
+			conf.Name = values[key]
+			break
+		}
+	}
+	if conf.Name == "" {
+		conf.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	for _, c := range strings.Split(values["Categories"], ";") {
+		if g, ok := desktopCategoryGroups[c]; ok {
+			conf.Group = g
+			break
+		}
+	}
+	if icon := values["Icon"]; icon != "" {
+		if p, err := resolveThemeIcon(icon); err == nil && !strings.HasSuffix(p, ".xpm") {
+			conf.Icon = p
+		}
+	}
+
+	switch values["Type"] {
+	case "Link":
+		if values["URL"] == "" {
+			return ToolConfig{}, errors.New("Link 类型的条目缺少 URL=")
+		}
+		conf.Type, conf.Path = WebTool, values["URL"]
+		return conf, nil
+	case "Application", "":
+	default:
+		return ToolConfig{}, fmt.Errorf("不支持 Type=%s", values["Type"])
+	}
+
+	args, err := splitArgs(desktopFieldCodes.ReplaceAllString(strings.ReplaceAll(values["Exec"], "%%", "%"), ""))
+	if err != nil {
+		return ToolConfig{}, err
+	}
+	// 去掉 Exec 开头的 env VAR=value，改为条目的环境变量
+	if len(args) > 0 && filepath.Base(args[0]) == "env" {
+		args = args[1:]
+		for len(args) > 0 && strings.Contains(args[0], "=") {
+			conf.Env = append(conf.Env, args[0])
+			args = args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return ToolConfig{}, errors.New("缺少 Exec=")
+	}
+	conf.Type = AppTool
+	conf.Path = args[0]
+	conf.WorkDir = values["Path"]
+	quoted := make([]string, len(args)-1)
+	for i, a := range args[1:] {
+		quoted[i] = quoteArg(a)
+	}
+	conf.Args = strings.Join(quoted, " ")
+	return conf, nil
+}
+
+// --- .lnk (MS-SHLLINK) ---
+
+const (
+	lnkHasTargetIDList = 1 << iota
+	lnkHasLinkInfo
+	lnkHasName
+	lnkHasRelativePath
+	lnkHasWorkingDir
+	lnkHasArguments
+	lnkHasIconLocation
+	lnkIsUnicode
+)
+
+// parseLnkFile 解析 Windows 快捷方式，取出目标路径、参数和工作目录
+func parseLnkFile(path string) (ToolConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ToolConfig{}, err
+	}
+	le := binary.LittleEndian
+	if len(data) < 0x4C || le.Uint32(data) != 0x4C {
+		return ToolConfig{}, errors.New("不是有效的快捷方式文件")
+	}
+	flags := le.Uint32(data[0x14:])
+	pos := 0x4C
+	errTruncated := errors.New("快捷方式文件不完整")
+
+	if flags&lnkHasTargetIDList != 0 {
+		if pos+2 > len(data) {
+			return ToolConfig{}, errTruncated
+		}
+		pos += 2 + int(le.Uint16(data[pos:]))
+	}
+
+	var target string
+	if flags&lnkHasLinkInfo != 0 {
+		if pos+28 > len(data) {
+			return ToolConfig{}, errTruncated
+		}
+		info := data[pos:]
+		size := int(le.Uint32(info))
+		if size > len(info) || size < 28 {
+			return ToolConfig{}, errTruncated
+		}
+		info = info[:size]
+		headerSize := le.Uint32(info[4:])
+		infoFlags := le.Uint32(info[8:])
+		if infoFlags&1 != 0 { // VolumeIDAndLocalBasePath
+			base := lnkCString(info, int(le.Uint32(info[16:])))
+			suffix := lnkCString(info, int(le.Uint32(info[24:])))
+			if headerSize >= 0x24 && len(info) >= 0x24 {
+				if off := int(le.Uint32(info[28:])); off > 0 {
+					base = lnkWideCString(info, off)
+				}
+				if off := int(le.Uint32(info[32:])); off > 0 {
+					suffix = lnkWideCString(info, off)
+				}
+			}
+			target = base + suffix
+		}
+		pos += size
+	}
+
+	readString := func() (string, error) {
+		if pos+2 > len(data) {
+			return "", errTruncated
+		}
+		n := int(le.Uint16(data[pos:]))
+		pos += 2
+		if flags&lnkIsUnicode != 0 {
+			if pos+n*2 > len(data) {
+				return "", errTruncated
+			}
+			s := decodeUTF16(data[pos : pos+n*2])
+			pos += n * 2
+			return s, nil
+		}
+		if pos+n > len(data) {
+			return "", errTruncated
+		}
+		s := decodeANSI(data[pos : pos+n])
+		pos += n
+		return s, nil
+	}
+	var description, relative, workDir, args string
+	for _, f := range []struct {
+		flag uint32
+		dst  *string
+	}{{lnkHasName, &description}, {lnkHasRelativePath, &relative}, {lnkHasWorkingDir, &workDir}, {lnkHasArguments, &args}} {
+		if flags&f.flag == 0 {
+			continue
+		}
+		if *f.dst, err = readString(); err != nil {
+			return ToolConfig{}, err
+		}
+	}
+	if target == "" && relative != "" {
+		target = filepath.Join(filepath.Dir(path), filepath.FromSlash(strings.ReplaceAll(relative, `\`, "/")))
+	}
+	if target == "" {
+		return ToolConfig{}, errors.New("快捷方式没有指向本地文件 (可能指向控制面板等特殊位置)")
+	}
+
+	conf := ToolConfig{
+		Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Type:    AppTool,
+		Path:    target,
+		Args:    args,
+		WorkDir: workDir,
+	}
+	switch ext := strings.ToLower(filepath.Ext(target)); {
+	case ext == ".exe" || ext == ".com":
+	case ext == ".bat" || ext == ".cmd" || ext == ".ps1":
+		conf.Type = ScriptTool
+	case ext == "":
+		conf.Type = FolderTool // 没有扩展名的目标通常是文件夹
+	default:
+		conf.Type = FileTool
+	}
+	return conf, nil
+}
+
+func lnkCString(b []byte, off int) string {
+	if off <= 0 || off >= len(b) {
+		return ""
+	}
+	end := bytes.IndexByte(b[off:], 0)
+	if end < 0 {
+		end = len(b) - off
+	}
+	return decodeANSI(b[off : off+end])
+}
+
+func lnkWideCString(b []byte, off int) string {
+	if off <= 0 || off >= len(b) {
+		return ""
+	}
+	end := off
+	for end+1 < len(b) && (b[end] != 0 || b[end+1] != 0) {
+		end += 2
+	}
+	return decodeUTF16(b[off:end])
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// decodeANSI 解码快捷方式中的 ANSI 字符串。中文系统的代码页是 GBK，不是合法 UTF-8 时按 GBK 解码。
+func decodeANSI(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	if s, err := simplifiedchinese.GBK.NewDecoder().Bytes(b); err == nil {
+		return string(s)
+	}
+	return string(b)
+}
+
+// --- 书签 ---
+
+var bookmarkTokenRe = regexp.MustCompile(`(?is)<h3[^>]*>(.*?)</h3>|<a\s[^>]*?href\s*=\s*"([^"]*)"[^>]*>(.*?)</a>|<dl[^>]*>|</dl>`)
+
+// parseBookmarksHTML 解析浏览器导出的 Netscape 格式书签，分组是书签所在的最内层文件夹
+func parseBookmarksHTML(doc string) []ToolConfig {
+	var confs []ToolConfig
+	var stack []string // 当前所在的文件夹
+	pending := ""      // 最近的 <H3>，它后面的 <DL> 是这个文件夹的内容
+	for _, m := range bookmarkTokenRe.FindAllStringSubmatch(doc, -1) {
+		token := strings.ToLower(m[0])
+		switch {
+		case strings.HasPrefix(token, "<h3"):
+			pending = html.UnescapeString(strings.TrimSpace(m[1]))
+		case strings.HasPrefix(token, "<dl"):
+			stack = append(stack, pending)
+			pending = ""
+		case token == "</dl>":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			href := html.UnescapeString(m[2])
+			if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+				continue // 跳过 javascript: 书签和 place: 查询
+			}
+			name := html.UnescapeString(strings.TrimSpace(m[3]))
+			if name == "" {
+				name = href
+			}
+			confs = append(confs, ToolConfig{Name: name, Type: WebTool, Path: href, Group: bookmarkGroup(stack)})
+		}
+	}
+	return confs
+}
+
+func bookmarkGroup(folders []string) string {
+	for i := len(folders) - 1; i >= 0; i-- {
+		if folders[i] != "" {
+			return folders[i]
+		}
+	}
+	return bookmarksGroup
+}
+
+// chromeNode 是 Chrome Bookmarks 文件中的书签或文件夹
+type chromeNode struct {
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	URL      string       `json:"url"`
+	Children []chromeNode `json:"children"`
+}
+
+// parseChromeBookmarks 解析 Chrome/Edge 用户目录中的 Bookmarks 文件
+func parseChromeBookmarks(data []byte) ([]ToolConfig, error) {
+	var file struct {
+		Roots map[string]json.RawMessage `json:"roots"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("无法解析 Chrome 书签: %v", err)
+	}
+	if len(file.Roots) == 0 {
+		return nil, errors.New("不是 Chrome 书签文件 (缺少 roots)")
+	}
+	var confs []ToolConfig
+	var walk func(node chromeNode, folders []string)
+	walk = func(node chromeNode, folders []string) {
+		switch node.Type {
+		case "url":
+			if strings.HasPrefix(node.URL, "http://") || strings.HasPrefix(node.URL, "https://") {
+				name := node.Name
+				if name == "" {
+					name = node.URL
+				}
+				confs = append(confs, ToolConfig{Name: name, Type: WebTool, Path: node.URL, Group: bookmarkGroup(folders)})
+			}
+		case "folder":
+			for _, child := range node.Children {
+				walk(child, append(folders, node.Name))
+			}
+		}
+	}
+	// 按 Chrome 中的显示顺序处理，roots 中还有 sync_transaction_version 等非文件夹字段
+	for _, key := range []string{"bookmark_bar", "other", "synced"} {
+		var root chromeNode
+		if raw, ok := file.Roots[key]; ok && json.Unmarshal(raw, &root) == nil {
+			walk(root, nil)
+		}
+	}
+	return confs, nil
+}
@@ -0,0 +1,430 @@
+package locallauncher
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// --- .lnk ---
+
+// testLnk 描述测试用快捷方式的内容，空字段不写入文件
+type testLnk struct {
+	idList        []byte
+	base, suffix  []byte // LinkInfo 中的 ANSI 路径
+	wideBase      string // 非空时写入 Unicode 版本的 LocalBasePath
+	unicode       bool   // 字符串数据使用 UTF-16
+	name, relPath string
+	workDir, args string
+}
+
+func (l testLnk) bytes() []byte {
+	le := binary.LittleEndian
+	data := make([]byte, 0x4C)
+	le.PutUint32(data, 0x4C)
+	var flags uint32
+	if l.idList != nil {
+		flags |= lnkHasTargetIDList
+		data = le.AppendUint16(data, uint16(len(l.idList)))
+		data = append(data, l.idList...)
+	}
+	if l.base != nil {
+		flags |= lnkHasLinkInfo
+		headerSize := 0x1C
+		if l.wideBase != "" {
+			headerSize = 0x24
+		}
+		info := make([]byte, headerSize)
+		le.PutUint32(info[4:], uint32(headerSize))
+		le.PutUint32(info[8:], 1) // VolumeIDAndLocalBasePath
+		le.PutUint32(info[12:], uint32(len(info)))
+		info = append(info, 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0) // VolumeID，内容不会被读取
+		le.PutUint32(info[16:], uint32(len(info)))
+		info = append(append(info, l.base...), 0)
+		le.PutUint32(info[24:], uint32(len(info)))
+		info = append(append(info, l.suffix...), 0)
+		if l.wideBase != "" {
+			le.PutUint32(info[28:], uint32(len(info)))
+			for _, u := range utf16.Encode([]rune(l.wideBase)) {
+				info = le.AppendUint16(info, u)
+			}
+			info = append(info, 0, 0)
+		}
+		le.PutUint32(info, uint32(len(info)))
+		data = append(data, info...)
+	}
+	for _, s := range []struct {
+		flag  uint32
+		value string
+	}{{lnkHasName, l.name}, {lnkHasRelativePath, l.relPath}, {lnkHasWorkingDir, l.workDir}, {lnkHasArguments, l.args}} {
+		if s.value == "" {
+			continue
+		}
+		flags |= s.flag
+		if l.unicode {
+			u := utf16.Encode([]rune(s.value))
+			data = le.AppendUint16(data, uint16(len(u)))
+			for _, c := range u {
+				data = le.AppendUint16(data, c)
+			}
+		} else {
+			data = le.AppendUint16(data, uint16(len(s.value)))
+			data = append(data, s.value...)
+		}
+	}
+	if l.unicode {
+		flags |= lnkIsUnicode
+	}
+	le.PutUint32(data[0x14:], flags)
+	return data
+}
+
+func gbk(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseLnkFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		lnk     testLnk
+		want    ToolConfig
+		wantErr bool
+	}{
+		{
+			name: "程序、参数和工作目录",
+			file: "编辑器.lnk",
+			lnk: testLnk{
+				idList: make([]byte, 20), base: []byte(`C:\Program Files\App\app.exe`), unicode: true,
+				name: "描述", workDir: `C:\Work`, args: `--open "a b.txt"`,
+			},
+			want: ToolConfig{Name: "编辑器", Type: AppTool, Path: `C:\Program Files\App\app.exe`, Args: `--open "a b.txt"`, WorkDir: `C:\Work`},
+		},
+		{
+			name: "基础路径和后缀",
+			file: "docs.lnk",
+			lnk:  testLnk{base: []byte(`C:\Users\`), suffix: []byte(`me\Documents`)},
+			want: ToolConfig{Name: "docs", Type: FolderTool, Path: `C:\Users\me\Documents`},
+		},
+		{
+			name: "GBK 编码的 ANSI 路径",
+			file: "script.lnk",
+			lnk:  testLnk{base: gbk(t, `D:\工具\备份.bat`)},
+			want: ToolConfig{Name: "script", Type: ScriptTool, Path: `D:\工具\备份.bat`},
+		},
+		{
+			name: "Unicode 路径优先于 ANSI 路径",
+			file: "photo.lnk",
+			lnk:  testLnk{base: []byte(`C:\????.jpg`), wideBase: `C:\照片.jpg`},
+			want: ToolConfig{Name: "photo", Type: FileTool, Path: `C:\照片.jpg`},
+		},
+		{
+			name: "没有 LinkInfo 时使用相对路径",
+			file: "readme.lnk",
+			lnk:  testLnk{relPath: `..\docs\readme.txt`},
+			want: ToolConfig{Name: "readme", Type: FileTool, Path: filepath.Join(dir, "..", "docs", "readme.txt")},
+		},
+		{name: "没有目标", file: "special.lnk", lnk: testLnk{idList: make([]byte, 4), name: "控制面板"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			data := tt.lnk.bytes()
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseLnkFile(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseLnkFile() = %+v, 期望出错", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLnkFile() 出错: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLnkFile() = %+v\n期望 %+v", got, tt.want)
+			}
+
+			// 截断的文件只能返回错误
+			for n := 0; n < len(data); n++ {
+				if err := os.WriteFile(path, data[:n], 0644); err != nil {
+					t.Fatal(err)
+				}
+				if got, err := parseLnkFile(path); err == nil {
+					t.Errorf("截断到 %d 字节时期望出错, 得到 %+v", n, got)
+				}
+			}
+		})
+	}
+
+	bad := filepath.Join(dir, "bad.lnk")
+	os.WriteFile(bad, []byte("not a shortcut, just some text that is long enough to fill the header......."), 0644)
+	if _, err := parseLnkFile(bad); err == nil {
+		t.Error("无效的文件头期望出错")
+	}
+}
+
+// --- .desktop ---
+
+func TestParseDesktopFile(t *testing.T) {
+	dir := t.TempDir()
+	icon := filepath.Join(dir, "app.png")
+	os.WriteFile(icon, nil, 0644)
+	xpm := filepath.Join(dir, "old.xpm")
+	os.WriteFile(xpm, nil, 0644)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    ToolConfig
+		wantErr error // nil 表示不出错；errAny 表示任意错误
+	}{
+		{
+			name: "程序和本地化名称",
+			file: "code.desktop",
+			content: `# comment
+[Desktop Entry]
+Type=Application
+Name=Code
+Name[zh_CN]=代码编辑器
+Name[zh_CN]=重复的键只取第一个
+Categories=GTK;Development;IDE;
+Exec=env FOO=1 BAR=a=b /usr/bin/code --new-window %F
+Path=/home/me
+Icon=` + icon + `
+
+[Desktop Action new]
+Name=New Window
+Exec=/usr/bin/code --other
+`,
+			want: ToolConfig{Name: "代码编辑器", Type: AppTool, Path: "/usr/bin/code", Args: "--new-window", WorkDir: "/home/me", Group: "开发", Icon: icon, Env: []string{"FOO=1", "BAR=a=b"}},
+		},
+		{
+			name: "带引号的参数和转义的百分号",
+			file: "my-app.desktop",
+			content: `[Desktop Entry]
+Exec="/opt/my app/run" "--title=a b" --rate=100%% %u
+Name[zh]=我的程序
+Categories=Unknown;Utility
+Icon=` + xpm + `
+`,
+			want: ToolConfig{Name: "我的程序", Type: AppTool, Path: "/opt/my app/run", Args: `"--title=a b" --rate=100%`, Group: "工具"},
+		},
+		{
+			name: "没有名称时使用文件名",
+			file: "nameless.desktop",
+			content: `[Desktop Entry]
+Exec=/bin/true
+`,
+			want: ToolConfig{Name: "nameless", Type: AppTool, Path: "/bin/true"},
+		},
+		{
+			name: "网站",
+			file: "site.desktop",
+			content: `[Desktop Entry]
+Type=Link
+Name=Site
+URL=https://example.com/
+Categories=Network;
+`,
+			want: ToolConfig{Name: "Site", Type: WebTool, Path: "https://example.com/", Group: "网络"},
+		},
+		{name: "不显示的条目", file: "hidden.desktop", content: "[Desktop Entry]\nNoDisplay=true\nExec=/bin/x\n", wantErr: errSkipDesktop},
+		{name: "隐藏的条目", file: "hidden2.desktop", content: "[Desktop Entry]\nHidden=true\nExec=/bin/x\n", wantErr: errSkipDesktop},
+		{name: "网站缺少 URL", file: "link.desktop", content: "[Desktop Entry]\nType=Link\nName=x\n", wantErr: errAny},
+		{name: "不支持的类型", file: "dir.desktop", content: "[Desktop Entry]\nType=Directory\nName=x\n", wantErr: errAny},
+		{name: "缺少 Exec", file: "noexec.desktop", content: "[Desktop Entry]\nName=x\n[Other]\nExec=/bin/x\n", wantErr: errAny},
+		{name: "Exec 只有环境变量", file: "envonly.desktop", content: "[Desktop Entry]\nExec=env A=1\n", wantErr: errAny},
+		{name: "Exec 引号不完整", file: "quote.desktop", content: "[Desktop Entry]\nExec=\"/bin/x\n", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseDesktopFile(path)
+			if tt.wantErr != nil {
+				if err == nil || tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseDesktopFile() = %+v, %v, 期望错误 %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDesktopFile() 出错: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDesktopFile() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// errAny 表示期望出错，但不关心具体的错误
+var errAny = errors.New("任意错误")
+
+// --- 书签 ---
+
+func TestParseBookmarksHTML(t *testing.T) {
+	doc := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://top.example/" ADD_DATE="1">Top &amp; Level</A>
+    <DT><H3 ADD_DATE="1" PERSONAL_TOOLBAR_FOLDER="true">书签栏</H3>
+    <DL><p>
+        <DT><A HREF="https://a.example/?x=1&amp;y=2">A</A>
+        <DT><H3>开发</H3>
+        <DL><p>
+            <DT><a href="http://go.dev" icon="data:image/png;base64,xx">  Go  </a>
+            <DT><A HREF="javascript:alert(1)">Script</A>
+            <DT><A HREF="place:sort=8">Recent</A>
+        </DL><p>
+        <DT><A HREF="https://b.example/"></A>
+    </DL><p>
+    <DT><H3>空文件夹</H3>
+    <DL><p>
+    </DL><p>
+    <DT><A HREF="https://after.example/">After</A>
+</DL><p>
+</DL>
+<DT><A HREF="https://unbalanced.example/">Unbalanced</A>
+`
+	want := []ToolConfig{
+		{Name: "Top & Level", Type: WebTool, Path: "https://top.example/", Group: bookmarksGroup},
+		{Name: "A", Type: WebTool, Path: "https://a.example/?x=1&y=2", Group: "书签栏"},
+		{Name: "Go", Type: WebTool, Path: "http://go.dev", Group: "开发"},
+		{Name: "https://b.example/", Type: WebTool, Path: "https://b.example/", Group: "书签栏"},
+		{Name: "After", Type: WebTool, Path: "https://after.example/", Group: bookmarksGroup},
+		{Name: "Unbalanced", Type: WebTool, Path: "https://unbalanced.example/", Group: bookmarksGroup},
+	}
+	if got := parseBookmarksHTML(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBookmarksHTML() =\n%+v\n期望\n%+v", got, want)
+	}
+	if got := parseBookmarksHTML("<html><body>nothing</body></html>"); len(got) != 0 {
+		t.Errorf("没有书签时返回了 %+v", got)
+	}
+}
+
+func TestParseChromeBookmarks(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []ToolConfig
+		wantErr bool
+	}{
+		{
+			name: "按显示顺序和最内层文件夹分组",
+			data: `{
+  "checksum": "x",
+  "roots": {
+    "synced": {"type": "folder", "name": "移动设备书签", "children": [
+      {"type": "url", "name": "M", "url": "https://m.example/"}
+    ]},
+    "other": {"type": "folder", "name": "", "children": [
+      {"type": "url", "name": "", "url": "https://noname.example/"}
+    ]},
+    "bookmark_bar": {"type": "folder", "name": "书签栏", "children": [
+      {"type": "url", "name": "A", "url": "https://a.example/"},
+      {"type": "folder", "name": "工作", "children": [
+        {"type": "url", "name": "B", "url": "http://b.example/"},
+        {"type": "url", "name": "Chrome", "url": "chrome://settings"},
+        {"type": "folder", "name": "", "children": [
+          {"type": "url", "name": "C", "url": "https://c.example/"}
+        ]}
+      ]},
+      {"type": "url", "name": "D", "url": "https://d.example/"}
+    ]},
+    "sync_transaction_version": "1"
+  },
+  "version": 1
+}`,
+			want: []ToolConfig{
+				{Name: "A", Type: WebTool, Path: "https://a.example/", Group: "书签栏"},
+				{Name: "B", Type: WebTool, Path: "http://b.example/", Group: "工作"},
+				{Name: "C", Type: WebTool, Path: "https://c.example/", Group: "工作"},
+				{Name: "D", Type: WebTool, Path: "https://d.example/", Group: "书签栏"},
+				{Name: "https://noname.example/", Type: WebTool, Path: "https://noname.example/", Group: bookmarksGroup},
+				{Name: "M", Type: WebTool, Path: "https://m.example/", Group: "移动设备书签"},
+			},
+		},
+		{name: "空的书签", data: `{"roots": {"bookmark_bar": {"type": "folder", "name": "书签栏", "children": []}}}`},
+		{name: "无效的 JSON", data: `{"roots": `, wantErr: true},
+		{name: "缺少 roots", data: `{"version": 1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChromeBookmarks([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseChromeBookmarks() = %+v, 期望出错", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseChromeBookmarks() 出错: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChromeBookmarks() =\n%+v\n期望\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("a.desktop", "[Desktop Entry]\nName=A\nExec=/bin/a\n")
+	write("b.DESKTOP", "[Desktop Entry]\nNoDisplay=true\nExec=/bin/b\n")
+	write("notes.txt", "not imported from a folder")
+	os.Mkdir(filepath.Join(dir, "sub.desktop"), 0755)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    int
+		wantErr bool
+	}{
+		{name: "Chrome 书签", path: write("Bookmarks", `{"roots":{"other":{"type":"folder","children":[{"type":"url","url":"https://x/"}]}}}`), want: 1},
+		{name: "书签 HTML", path: write("bookmarks.html", "<!DOCTYPE NETSCAPE-Bookmark-file-1>\n<DL><DT><A HREF=\"https://x/\">X</A></DL>"), want: 1},
+		{name: "没有文件头的书签 HTML", path: write("export.htm", "<dl><DT><A HREF=\"https://x/\">X</A></dl>"), want: 1},
+		{name: ".desktop", path: filepath.Join(dir, "a.desktop"), want: 1},
+		{name: "无法识别的格式", path: filepath.Join(dir, "notes.txt"), wantErr: true},
+		{name: "文件不存在", path: filepath.Join(dir, "missing.html"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportFile(tt.path)
+			if tt.wantErr != (err != nil) || len(got) != tt.want {
+				t.Errorf("parseImportFile() = %d 个条目, %v", len(got), err)
+			}
+		})
+	}
+
+	// 文件夹中只导入 .desktop 和 .lnk，无法解析的文件单独报告
+	confs, errs := parseImportDir(dir)
+	if len(confs) != 1 || confs[0].Name != "A" || len(errs) != 1 {
+		t.Errorf("parseImportDir() = %+v, %v", confs, errs)
+	}
+}
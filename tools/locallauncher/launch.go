@@ -0,0 +1,298 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/skratchdot/open-golang/open"
+)
+
+// launch 启动第 index 个条目并记录启动统计，失败时提示用户并返回 false。只在 UI 线程中调用。
+func (t *localLauncherTool) launch(index int) bool {
+	conf := t.configs[index]
+	if _, err := t.start(conf, nil); err != nil {
+		log.Printf("启动 %s 失败: %v", conf.Name, err)
+		dialog.ShowError(err, t.win)
+		return false
+	}
+	t.configs[index].LaunchCount++
+	t.configs[index].LastLaunch = time.Now()
+	t.saveConfig()
+	return true
+}
+
+// start 按类型启动条目。对于脚本和启动组合，返回的 done 会在其运行结束时收到结果，
+// 其他类型启动后就与启动器无关，done 为 nil。chain 是正在运行的外层启动组合，用于检测循环引用。
+func (t *localLauncherTool) start(conf ToolConfig, chain []string) (done <-chan error, err error) {
+	if conf, err = conf.resolved(); err != nil {
+		return nil, fmt.Errorf("'%s' 的路径无效: %v", conf.Name, err)
+	}
+	log.Printf("正在启动: %s (%s)", conf.Path, conf.Type)
+	switch conf.Type {
+	case AppTool:
+		cmd, err := buildCommand(conf)
+		if err != nil {
+			return nil, err
+		}
+		if launchesDirectly(conf) {
+			_, _, err = startTracked(conf, cmd)
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		go cmd.Wait() // 只回收中间进程
+		return nil, nil
+	case WebTool:
+		u, err := url.Parse(conf.Path)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fyne.CurrentApp().OpenURL(u)
+	case FolderTool, FileTool:
+		info, err := os.Stat(conf.Path)
+		if err != nil {
+			return nil, err
+		}
+		if conf.Type == FolderTool && !info.IsDir() {
+			return nil, fmt.Errorf("%s 不是文件夹", conf.Path)
+		}
+		return nil, open.Start(conf.Path)
+	case ScriptTool:
+		return t.runScript(conf)
+	case ChainTool:
+		return t.runChain(conf, chain)
+	}
+	return nil, errors.New("未知的工具类型: " + string(conf.Type))
+}
+
+// splitArgs 把参数字符串拆分为参数列表。空白分隔参数，单引号和双引号内的空白保留；
+// 只有双引号内的 \" 和 \\ 是转义序列，其余反斜杠按原样保留，
+// 这样 Windows 路径 (包括 \\nas\share 这样的网络路径) 无需转义。
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') && quote == '"':
+			i++
+			cur.WriteRune(runes[i])
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("参数中的引号 %c 没有闭合", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// quoteArg 在需要时给参数加上双引号，结果可以被 splitArgs 还原
+func quoteArg(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\"'") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parseEnvText 解析每行一个 KEY=VALUE 的环境变量文本，空行和 # 开头的行被忽略
+func parseEnvText(text string) ([]string, error) {
+	var env []string
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("环境变量第 %d 行格式应为 KEY=VALUE: %s", i+1, line)
+		}
+		env = append(env, line)
+	}
+	return env, nil
+}
+
+// workDir 返回启动时使用的工作目录，未设置时为程序所在的文件夹
+func (c ToolConfig) workDir() string {
+	if c.WorkDir != "" {
+		return c.WorkDir
+	}
+	return filepath.Dir(c.Path)
+}
+
+// buildCommand 根据条目的路径、参数、工作目录、环境变量和提权设置构造要执行的命令
+func buildCommand(conf ToolConfig) (*exec.Cmd, error) {
+	args, err := splitArgs(conf.Args)
+	if err != nil {
+		return nil, err
+	}
+	dir := conf.workDir()
+	if conf.Elevated {
+		return elevatedCommand(conf.Path, args, dir, conf.Env)
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" && !strings.EqualFold(filepath.Ext(conf.Path), ".exe") {
+		// 快捷方式、批处理等交给 start，由系统决定如何打开
+		cmd = exec.Command("cmd", append([]string{"/C", "start", "", conf.Path}, args...)...)
+	} else {
+		cmd = exec.Command(conf.Path, args...)
+	}
+	cmd.Dir = dir
+	if len(conf.Env) > 0 {
+		// 重复的变量以最后一个为准，所以这里的设置会覆盖继承来的值
+		cmd.Env = append(os.Environ(), conf.Env...)
+	}
+	return cmd, nil
+}
+
+// launchesDirectly 判断 buildCommand 启动的进程是否就是程序本身，而不是 start 或提权工具这样的中间进程
+func launchesDirectly(conf ToolConfig) bool {
+	return !conf.Elevated && (runtime.GOOS != "windows" || strings.EqualFold(filepath.Ext(conf.Path), ".exe"))
+}
+
+// scriptCommand 根据扩展名选择解释器。没有可识别扩展名的脚本在 Windows 上交给 cmd，
+// 在其他系统上有执行权限时直接运行 (使用脚本的 #! 行)，否则交给 sh。
+func scriptCommand(conf ToolConfig) (*exec.Cmd, error) {
+	args, err := splitArgs(conf.Args)
+	if err != nil {
+		return nil, err
+	}
+	var prefix []string
+	switch strings.ToLower(filepath.Ext(conf.Path)) {
+	case ".bat", ".cmd":
+		prefix = []string{"cmd", "/C"}
+	case ".ps1":
+		prefix = []string{"powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-File"}
+	case ".py":
+		prefix = []string{"python3"}
+		if runtime.GOOS == "windows" {
+			prefix = []string{"python"}
+		}
+	default:
+		if runtime.GOOS == "windows" {
+			prefix = []string{"cmd", "/C"}
+		} else if info, err := os.Stat(conf.Path); err != nil || info.Mode()&0111 == 0 {
+			prefix = []string{"sh"}
+		}
+	}
+	argv := append(append(prefix, conf.Path), args...)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = conf.workDir()
+	if len(conf.Env) > 0 {
+		cmd.Env = append(os.Environ(), conf.Env...)
+	}
+	return cmd, nil
+}
+
+// errElevatedEnv 表示 Windows 上以管理员身份运行时不能设置环境变量
+var errElevatedEnv = errors.New("Windows 上以管理员身份运行的程序无法设置环境变量，请清空环境变量或取消以管理员身份运行")
+
+// elevatedCommand 通过系统的提权工具 (Windows 的 UAC、Linux 的 pkexec、macOS 的管理员授权) 启动程序
+func elevatedCommand(path string, args []string, dir string, env []string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "windows":
+		// UAC 提权后的进程不继承当前进程的环境变量，Start-Process -Verb RunAs 也无法传入
+		if len(env) > 0 {
+			return nil, errElevatedEnv
+		}
+		ps := "Start-Process -Verb RunAs -FilePath " + psQuote(path) + " -WorkingDirectory " + psQuote(dir)
+		if len(args) > 0 {
+			quoted := make([]string, len(args))
+			for i, a := range args {
+				quoted[i] = psQuote(a)
+			}
+			ps += " -ArgumentList " + strings.Join(quoted, ",")
+		}
+		return exec.Command("powershell", "-NoProfile", "-WindowStyle", "Hidden", "-Command", ps), nil
+	case "linux":
+		// pkexec 会清空环境变量并切换到根目录，所以交给 env 重新设置
+		return exec.Command("pkexec", append(append([]string{"env", "--chdir=" + dir}, env...), append([]string{path}, args...)...)...), nil
+	case "darwin":
+		parts := []string{"cd", shQuote(dir), "&&"}
+		if len(env) > 0 {
+			parts = append(parts, "env")
+			for _, e := range env {
+				parts = append(parts, shQuote(e))
+			}
+		}
+		parts = append(parts, shQuote(path))
+		for _, a := range args {
+			parts = append(parts, shQuote(a))
+		}
+		script := strings.Join(parts, " ")
+		apple := `do shell script "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(script) + `" with administrator privileges`
+		return exec.Command("osascript", "-e", apple), nil
+	}
+	return nil, errors.New("当前系统不支持以管理员身份运行")
+}
+
+func psQuote(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+
+func shQuote(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" }
+
+// commandPreview 返回条目最终执行的命令行，用于在编辑对话框中预览
+func commandPreview(conf ToolConfig) string {
+	if conf.pathForOS() == "" {
+		return "(未设置路径)"
+	}
+	conf, err := conf.resolved()
+	if err != nil {
+		return "错误: " + err.Error()
+	}
+	build := buildCommand
+	if conf.Type == ScriptTool {
+		build = scriptCommand
+	}
+	cmd, err := build(conf)
+	if err != nil {
+		return "错误: " + err.Error()
+	}
+	quoted := make([]string, len(cmd.Args))
+	for i, a := range cmd.Args {
+		quoted[i] = quoteArg(a)
+	}
+	lines := []string{strings.Join(quoted, " ")}
+	if cmd.Dir != "" {
+		lines = append(lines, "工作目录: "+cmd.Dir)
+	}
+	// 提权时环境变量已经包含在命令行中 (env 或 shell 脚本)，只有直接设置给进程的才单独显示
+	if cmd.Env != nil {
+		lines = append(lines, "环境变量: "+strings.Join(conf.Env, " "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,270 @@
+package locallauncher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "  -a\t-b \n c  ", want: []string{"-a", "-b", "c"}},
+		{in: `"with space" 'single quoted'`, want: []string{"with space", "single quoted"}},
+		{in: `--name="a b"c`, want: []string{"--name=a bc"}},
+		{in: `"" ''`, want: []string{"", ""}},
+		{in: `"say \"hi\""`, want: []string{`say "hi"`}},
+		{in: `"a\\b"`, want: []string{`a\b`}},
+		{in: `'it\"s'`, want: []string{`it\"s`}}, // 单引号内没有转义
+		{in: `C:\dir\ D:\x`, want: []string{`C:\dir\`, `D:\x`}},
+		{in: `\\nas\share\file.txt`, want: []string{`\\nas\share\file.txt`}}, // 网络路径
+		{in: `"\\\\nas\\share dir"`, want: []string{`\\nas\share dir`}},
+		{in: `"C:\Program Files\app"`, want: []string{`C:\Program Files\app`}},
+		{in: `"unclosed`, wantErr: true},
+		{in: `'unclosed`, wantErr: true},
+		{in: `"C:\dir\"`, wantErr: true}, // 双引号内的 \" 是转义，引号没有闭合
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := splitArgs(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitArgs(%q) = %q, 期望出错", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitArgs(%q) 出错: %v", tt.in, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitArgs(%q) = %q, 期望 %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteArgRoundTrip(t *testing.T) {
+	args := []string{
+		"plain",
+		"",
+		"with space",
+		`C:\dir\`,
+		`C:\Program Files\dir\`,
+		`\\nas\share`,
+		`\\nas\share name\`,
+		`say "hi"`,
+		`it's`,
+		"tab\tand\nnewline",
+		`\"`,
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quoteArg(a)
+	}
+	line := strings.Join(quoted, " ")
+	got, err := splitArgs(line)
+	if err != nil {
+		t.Fatalf("splitArgs(%q) 出错: %v", line, err)
+	}
+	if !slices.Equal(got, args) {
+		t.Errorf("splitArgs(%q) = %q, 期望 %q", line, got, args)
+	}
+	// 不需要引号的参数保持原样
+	for _, a := range []string{"plain", `C:\dir\`, `\\nas\share`} {
+		if q := quoteArg(a); q != a {
+			t.Errorf("quoteArg(%q) = %q, 期望不变", a, q)
+		}
+	}
+}
+
+func TestParseEnvText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr string // 错误信息中应包含的内容
+	}{
+		{name: "空文本", text: "", want: nil},
+		{name: "空行、注释和首尾空白", text: "\r\n# comment\n  A=1  \r\n\nEMPTY=\n", want: []string{"A=1", "EMPTY="}},
+		{name: "值中可以有等号和空格", text: "OPTS=-a=b c", want: []string{"OPTS=-a=b c"}},
+		{name: "缺少等号", text: "A=1\nNOVALUE", wantErr: "第 2 行"},
+		{name: "缺少变量名", text: " = x", wantErr: "第 1 行"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvText(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseEnvText() = %q, %v, 期望错误包含 %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEnvText() 出错: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseEnvText() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 上通过 cmd /C start 启动")
+	}
+	app := filepath.Join(t.TempDir(), "bin", "tool")
+	tests := []struct {
+		name     string
+		goos     string // 只在这个系统上测试
+		conf     ToolConfig
+		wantArgs []string
+		wantDir  string
+		wantEnv  []string // 追加在继承的环境变量之后
+		wantErr  bool
+	}{
+		{
+			name:     "参数和默认工作目录",
+			conf:     ToolConfig{Path: app, Args: `-v "a b" C:\dir\`},
+			wantArgs: []string{app, "-v", "a b", `C:\dir\`},
+			wantDir:  filepath.Dir(app),
+		},
+		{
+			name:     "工作目录和环境变量",
+			conf:     ToolConfig{Path: app, WorkDir: "/work", Env: []string{"A=1", "PATH=/x"}},
+			wantArgs: []string{app},
+			wantDir:  "/work",
+			wantEnv:  []string{"A=1", "PATH=/x"},
+		},
+		{name: "引号没有闭合", conf: ToolConfig{Path: app, Args: `"a`}, wantErr: true},
+		{
+			name:     "以管理员身份运行",
+			goos:     "linux",
+			conf:     ToolConfig{Path: app, Args: "x", Env: []string{"A=1"}, Elevated: true},
+			wantArgs: []string{"pkexec", "env", "--chdir=" + filepath.Dir(app), "A=1", app, "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.goos != "" && tt.goos != runtime.GOOS {
+				t.Skipf("只在 %s 上测试", tt.goos)
+			}
+			cmd, err := buildCommand(tt.conf)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildCommand() = %q, 期望出错", cmd.Args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildCommand() 出错: %v", err)
+			}
+			if !slices.Equal(cmd.Args, tt.wantArgs) {
+				t.Errorf("Args = %q, 期望 %q", cmd.Args, tt.wantArgs)
+			}
+			if cmd.Dir != tt.wantDir {
+				t.Errorf("Dir = %q, 期望 %q", cmd.Dir, tt.wantDir)
+			}
+			if tt.wantEnv == nil {
+				if cmd.Env != nil {
+					t.Errorf("Env = %q, 期望继承当前进程的环境变量", cmd.Env)
+				}
+				return
+			}
+			if len(cmd.Env) != len(os.Environ())+len(tt.wantEnv) || !slices.Equal(cmd.Env[len(cmd.Env)-len(tt.wantEnv):], tt.wantEnv) {
+				t.Errorf("Env 的末尾 = %q, 期望 %q", cmd.Env[max(0, len(cmd.Env)-len(tt.wantEnv)):], tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestScriptCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 上没有扩展名的脚本交给 cmd")
+	}
+	dir := t.TempDir()
+	script := func(name string, mode os.FileMode) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	sh, exe, py, bat, ps1 := script("run.sh", 0644), script("tool", 0755), script("task.py", 0644), script("x.BAT", 0644), script("s.ps1", 0644)
+	tests := []struct {
+		name     string
+		conf     ToolConfig
+		wantArgs []string
+		wantDir  string
+	}{
+		{name: "没有执行权限时交给 sh", conf: ToolConfig{Path: sh, Args: "a 'b c'"}, wantArgs: []string{"sh", sh, "a", "b c"}, wantDir: dir},
+		{name: "有执行权限时直接运行", conf: ToolConfig{Path: exe}, wantArgs: []string{exe}, wantDir: dir},
+		{name: "Python", conf: ToolConfig{Path: py, WorkDir: "/tmp"}, wantArgs: []string{"python3", py}, wantDir: "/tmp"},
+		{name: "批处理 (扩展名不区分大小写)", conf: ToolConfig{Path: bat}, wantArgs: []string{"cmd", "/C", bat}, wantDir: dir},
+		{name: "PowerShell", conf: ToolConfig{Path: ps1}, wantArgs: []string{"powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-File", ps1}, wantDir: dir},
+		{name: "不存在的脚本交给 sh", conf: ToolConfig{Path: filepath.Join(dir, "missing")}, wantArgs: []string{"sh", filepath.Join(dir, "missing")}, wantDir: dir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := scriptCommand(tt.conf)
+			if err != nil {
+				t.Fatalf("scriptCommand() 出错: %v", err)
+			}
+			if !slices.Equal(cmd.Args, tt.wantArgs) {
+				t.Errorf("Args = %q, 期望 %q", cmd.Args, tt.wantArgs)
+			}
+			if cmd.Dir != tt.wantDir {
+				t.Errorf("Dir = %q, 期望 %q", cmd.Dir, tt.wantDir)
+			}
+		})
+	}
+	if _, err := scriptCommand(ToolConfig{Path: sh, Args: `'a`}); err == nil {
+		t.Error("引号没有闭合时期望出错")
+	}
+}
+
+func TestCommandPreview(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 上通过 cmd /C start 启动")
+	}
+	dir := t.TempDir()
+	app := filepath.Join(dir, "my app")
+	sh := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(sh, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		conf ToolConfig
+		want string
+	}{
+		{name: "未设置路径", conf: ToolConfig{Type: AppTool}, want: "(未设置路径)"},
+		{
+			name: "参数、工作目录和环境变量",
+			conf: ToolConfig{Type: AppTool, Path: app, Args: `"a b" c`, Env: []string{"K=v", "X=1"}},
+			want: quoteArg(app) + ` "a b" c` + "\n工作目录: " + dir + "\n环境变量: K=v X=1",
+		},
+		{
+			name: "当前系统的路径",
+			conf: ToolConfig{Type: AppTool, Path: "/elsewhere", Paths: map[string]string{runtime.GOOS: app}, WorkDir: "/w"},
+			want: quoteArg(app) + "\n工作目录: /w",
+		},
+		{name: "脚本", conf: ToolConfig{Type: ScriptTool, Path: sh}, want: "sh " + sh + "\n工作目录: " + dir},
+		{name: "未知的变量", conf: ToolConfig{Type: AppTool, Path: "${NOPE}/x"}, want: "错误: 未知的变量 ${NOPE}"},
+		{name: "参数有误", conf: ToolConfig{Type: AppTool, Path: app, Args: `"a`}, want: "错误: 参数中的引号 \" 没有闭合"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandPreview(tt.conf); got != tt.want {
+				t.Errorf("commandPreview() =\n%s\n期望\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,15 +3,14 @@ package locallauncher
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"log"
-	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 	"yanshu-toolkit/core" // 请确保这里的路径是您项目中 core 包的正确导入路径
 
 	"fyne.io/fyne/v2"
@@ -32,28 +31,79 @@ const (
 type ToolType string
 
 const (
-	AppTool ToolType = "app"
-	WebTool ToolType = "web"
+	AppTool    ToolType = "app"
+	WebTool    ToolType = "web"
+	FolderTool ToolType = "folder" // 在文件管理器中打开
+	FileTool   ToolType = "file"   // 用系统默认程序打开
+	ScriptTool ToolType = "script" // 运行脚本并在窗口中显示输出
+	ChainTool  ToolType = "chain"  // 依次或同时启动多个条目
 )
 
+// toolTypes 是编辑对话框中类型的显示顺序
+var toolTypes = []ToolType{AppTool, WebTool, FolderTool, FileTool, ScriptTool, ChainTool}
+
+var toolTypeLabels = map[ToolType]string{
+	AppTool:    "本地软件",
+	WebTool:    "网站",
+	FolderTool: "文件夹",
+	FileTool:   "文件",
+	ScriptTool: "脚本",
+	ChainTool:  "启动组合",
+}
+
 type ToolConfig struct {
+	ID    string   `json:"id,omitempty"` // 不随改名变化，启动组合、运行记录和快捷键用它引用条目
 	Name  string   `json:"name"`
 	Type  ToolType `json:"type"`
 	Path  string   `json:"path"`
 	Icon  string   `json:"icon"`
 	Group string   `json:"group"`
+
+	// Paths 为其他系统指定不同的路径，键为 runtime.GOOS；当前系统没有设置时使用 Path。
+	// Path、Icon 和 WorkDir 都可以使用 ${HOME} 等变量，见 portable.go
+	Paths map[string]string `json:"paths,omitempty"`
+
+	Favorite bool   `json:"favorite,omitempty"` // 显示在托盘菜单的第一级
+	Hotkey   string `json:"hotkey,omitempty"`   // 全局快捷键，例如 Ctrl+Alt+T，见 hotkey.go
+
+	// 以下只对本地软件有效
+	Args     string   `json:"args,omitempty"`     // 命令行参数，支持引号
+	WorkDir  string   `json:"work_dir,omitempty"` // 为空时使用程序所在的文件夹
+	Env      []string `json:"env,omitempty"`      // KEY=VALUE，覆盖继承的环境变量
+	Elevated bool     `json:"elevated,omitempty"` // 以管理员身份运行
+
+	// 以下只对启动组合有效
+	Steps    []ChainStep `json:"steps,omitempty"`
+	Parallel bool        `json:"parallel,omitempty"` // 同时启动所有步骤，而不是依次启动
+
+	// 启动统计，用于搜索结果排序
+	LaunchCount int       `json:"launch_count,omitempty"`
+	LastLaunch  time.Time `json:"last_launch,omitzero"`
 }
 
 type SaveData struct {
-	Configs    []ToolConfig `json:"configs"`
-	GroupOrder []string     `json:"group_order"`
+	Configs    []ToolConfig     `json:"configs"`
+	GroupOrder []string         `json:"group_order"`
+	Settings   LauncherSettings `json:"settings"`
+}
+
+// LauncherSettings 是托盘和全局快捷键的设置
+type LauncherSettings struct {
+	CloseToTray  bool   `json:"close_to_tray,omitempty"` // 关闭窗口时隐藏到托盘，而不是退出
+	SummonHotkey string `json:"summon_hotkey,omitempty"` // 显示工具箱并打开快速启动面板的全局快捷键
 }
 
 type localLauncherTool struct {
-	configs    []ToolConfig
-	groupOrder []string
-	win        fyne.Window
-	container  *fyne.Container
+	configs     []ToolConfig
+	groupOrder  []string
+	win         fyne.Window
+	container   *fyne.Container
+	body        *fyne.Container // 分组视图或搜索结果
+	searchEntry *widget.Entry
+	dropTargets []dropTarget // 分组视图中的分组和条目，用于拖放
+	drag        *dragState
+	settings    LauncherSettings
+	loadErr     error // 配置文件无法读取时不保存，以免覆盖
 }
 
 func New() core.Tool {
@@ -68,13 +118,31 @@ func (t *localLauncherTool) Icon() fyne.Resource { return theme.HomeIcon() }
 func (t *localLauncherTool) Category() string    { return "常用工具" }
 
 func (t *localLauncherTool) Destroy() {
+	if activeTool == t {
+		activeTool = nil
+	}
 }
 
 func (t *localLauncherTool) View(win fyne.Window) fyne.CanvasObject {
 	t.win = win
 	t.loadConfig()
-	t.container = container.NewStack()
+	activeTool = t
+	t.searchEntry = widget.NewEntry()
+	t.searchEntry.SetPlaceHolder("搜索名称、拼音首字母、分组或路径，回车启动第一个结果 (Ctrl+Space 打开快速启动)")
+	t.searchEntry.OnChanged = func(string) { t.refreshUI() }
+	t.searchEntry.OnSubmitted = func(query string) {
+		if results := t.search(query); len(results) > 0 {
+			t.launch(results[0])
+		}
+	}
+	t.body = container.NewStack()
+	refreshIconsBtn := widget.NewButtonWithIcon("刷新图标", theme.ViewRefreshIcon(), func() { t.refreshIcons(nil, true) })
+	var importBtn *widget.Button
+	importBtn = widget.NewButtonWithIcon("导入...", theme.DownloadIcon(), func() { t.showImportMenu(importBtn) })
+	settingsBtn := widget.NewButtonWithIcon("", theme.SettingsIcon(), t.showSettingsDialog)
+	t.container = container.NewBorder(container.NewBorder(nil, nil, nil, container.NewHBox(importBtn, refreshIconsBtn, settingsBtn), t.searchEntry), nil, nil, nil, t.body)
 	t.refreshUI()
+	t.refreshIcons(nil, false)
 	return t.container
 }
 
@@ -83,38 +151,56 @@ func (t *localLauncherTool) configPath() string {
 	return filepath.Join(configDir, configFileName)
 }
 
+// loadConfig 读取配置文件。文件损坏时从备份恢复并提示用户；无法读取时不允许保存，以免覆盖原有配置。
 func (t *localLauncherTool) loadConfig() {
 	path := t.configPath()
-	data, err := ioutil.ReadFile(path)
+	t.configs, t.groupOrder, t.settings, t.loadErr = []ToolConfig{}, []string{}, LauncherSettings{}, nil
+	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			t.configs = []ToolConfig{}
-			t.groupOrder = []string{}
-		} else {
+		if !os.IsNotExist(err) {
 			log.Printf("读取配置文件失败: %v", err)
+			t.loadErr = err
+			t.showError(fmt.Errorf("读取启动器配置失败，本次的修改不会被保存: %v", err))
 		}
 		return
 	}
 
-	var saveData SaveData
-	err = json.Unmarshal(data, &saveData)
+	saveData, legacy, err := parseSaveData(data)
 	if err != nil {
-		var oldConfigs []ToolConfig
-		if json.Unmarshal(data, &oldConfigs) == nil {
-			log.Println("检测到旧版配置文件，正在迁移...")
-			t.configs = oldConfigs
-			t.buildGroupOrderFromConfigs()
-			t.saveConfig()
+		log.Printf("解析配置文件失败: %v", err)
+		recovered, from, recoverErr := recoverConfig(path, time.Now())
+		switch {
+		case from != "":
+			log.Printf("已从备份 %s 恢复配置", from)
+			saveData = recovered
+			msg := fmt.Sprintf("启动器配置文件已损坏 (%v)，已从备份 %s 恢复。\n损坏的文件已改名保留在 %s 中。", err, filepath.Base(from), configDir)
+			if recoverErr != nil {
+				msg += "\n" + recoverErr.Error()
+			}
+			t.showInfo("配置已恢复", msg)
+		case recoverErr != nil:
+			t.loadErr = recoverErr
+			t.showError(fmt.Errorf("启动器配置文件已损坏 (%v)，%v。本次的修改不会被保存。", err, recoverErr))
+			return
+		default:
+			t.showError(fmt.Errorf("启动器配置文件已损坏 (%v)，并且没有可用的备份。损坏的文件已改名保留在 %s 中。", err, configDir))
 			return
 		}
-		log.Printf("解析配置文件失败: %v", err)
-		t.configs = []ToolConfig{}
-		t.groupOrder = []string{}
-		return
 	}
 
 	t.configs = saveData.Configs
 	t.groupOrder = saveData.GroupOrder
+	t.settings = saveData.Settings
+	if legacy {
+		log.Println("检测到旧版配置文件，正在迁移...")
+		t.buildGroupOrderFromConfigs()
+		t.saveConfig()
+		return
+	}
+	// 立即保存新分配的 ID，否则每次读取配置得到的 ID 都不同，托盘和快捷键就找不到条目
+	if t.ensureIDs() {
+		t.saveConfig()
+	}
 	t.cleanupAndValidateData()
 }
 
@@ -143,6 +229,7 @@ func (t *localLauncherTool) buildGroupOrderFromConfigs() {
 }
 
 func (t *localLauncherTool) cleanupAndValidateData() {
+	t.ensureIDs()
 	configGroups := make(map[string]bool)
 	for i := range t.configs {
 		if t.configs[i].Group == "" {
@@ -192,32 +279,76 @@ func (t *localLauncherTool) cleanupAndValidateData() {
 	t.groupOrder = finalOrder
 }
 
+// saveConfig 备份并原子地写入配置文件，失败时提示用户
 func (t *localLauncherTool) saveConfig() {
+	if t.loadErr != nil {
+		t.showError(fmt.Errorf("启动器配置读取失败，为避免覆盖原有配置，修改没有保存: %v", t.loadErr))
+		return
+	}
+	t.ensureIDs()
 	path := t.configPath()
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		os.MkdirAll(configDir, 0755)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.showError(fmt.Errorf("无法创建配置文件夹: %v", err))
+		return
 	}
-	saveData := SaveData{Configs: t.configs, GroupOrder: t.groupOrder}
-	data, err := json.MarshalIndent(saveData, "", "  ")
+	data, err := json.MarshalIndent(SaveData{Configs: t.configs, GroupOrder: t.groupOrder, Settings: t.settings}, "", "  ")
 	if err != nil {
 		log.Printf("序列化配置失败: %v", err)
+		t.showError(fmt.Errorf("保存启动器配置失败: %v", err))
 		return
 	}
-	err = ioutil.WriteFile(path, data, 0644)
-	if err != nil {
+	if err := backupConfig(path, time.Now()); err != nil {
+		log.Printf("备份配置文件失败: %v", err) // 备份失败不影响保存
+	}
+	if err := writeFileAtomic(path, data); err != nil {
 		log.Printf("写入配置文件失败: %v", err)
+		t.showError(fmt.Errorf("保存启动器配置失败: %v", err))
+		return
+	}
+	updateDesktopIntegration(t)
+}
+
+func (t *localLauncherTool) showError(err error) {
+	if t.win != nil {
+		dialog.ShowError(err, t.win)
+	}
+}
+
+func (t *localLauncherTool) showInfo(title, msg string) {
+	if t.win != nil {
+		dialog.ShowInformation(title, msg, t.win)
 	}
 }
 
 // --- UI 构建 ---
 func (t *localLauncherTool) refreshUI() {
 	t.cleanupAndValidateData() // Refresh UI時也清理一下数据保证同步
-	if len(t.configs) == 0 {
-		t.container.Objects = []fyne.CanvasObject{t.createEmptyView()}
-	} else {
-		t.container.Objects = []fyne.CanvasObject{t.createGridView()}
+	t.dropTargets = nil
+	switch {
+	case len(t.configs) == 0:
+		t.searchEntry.Hide()
+		t.body.Objects = []fyne.CanvasObject{t.createEmptyView()}
+	case strings.TrimSpace(t.searchEntry.Text) != "":
+		t.searchEntry.Show()
+		t.body.Objects = []fyne.CanvasObject{t.createSearchView(t.searchEntry.Text)}
+	default:
+		t.searchEntry.Show()
+		t.body.Objects = []fyne.CanvasObject{t.createGridView()}
 	}
-	t.container.Refresh()
+	t.body.Refresh()
+}
+
+// createSearchView 把匹配的条目按排名平铺显示
+func (t *localLauncherTool) createSearchView(query string) fyne.CanvasObject {
+	results := t.search(query)
+	if len(results) == 0 {
+		return widget.NewLabelWithStyle("没有匹配的条目", fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	}
+	buttons := make([]fyne.CanvasObject, len(results))
+	for i, index := range results {
+		buttons[i] = t.createToolButton(index)
+	}
+	return container.NewScroll(container.NewGridWrap(fyne.NewSize(100, 100), buttons...))
 }
 
 func (t *localLauncherTool) createEmptyView() fyne.CanvasObject {
@@ -263,14 +394,18 @@ func (t *localLauncherTool) createGridView() fyne.CanvasObject {
 		if groupName == defaultGroup {
 			titleBar = container.NewHBox(titleLabel)
 		} else {
-			titleBar = container.NewHBox(titleLabel, layout.NewSpacer(), upBtn, downBtn)
+			renameBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() { t.showRenameGroupDialog(groupName) })
+			deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { t.showDeleteGroupDialog(groupName) })
+			titleBar = container.NewHBox(titleLabel, layout.NewSpacer(), renameBtn, deleteBtn, upBtn, downBtn)
 		}
 
 		indices := groupsMap[groupName]
 		gridContent := make([]fyne.CanvasObject, 0, len(indices)+1)
 		if indices != nil {
 			for _, index := range indices {
-				gridContent = append(gridContent, t.createToolButton(index))
+				btn := t.createToolButton(index)
+				t.dropTargets = append(t.dropTargets, dropTarget{obj: btn, group: groupName, index: index})
+				gridContent = append(gridContent, btn)
 			}
 		}
 
@@ -289,7 +424,9 @@ func (t *localLauncherTool) createGridView() fyne.CanvasObject {
 		}
 		contentCard := widget.NewCard("", "", content)
 
-		allGroupsContent.Add(container.NewVBox(titleBar, contentCard))
+		groupBox := container.NewVBox(titleBar, contentCard)
+		t.dropTargets = append(t.dropTargets, dropTarget{obj: groupBox, group: groupName, index: -1})
+		allGroupsContent.Add(groupBox)
 	}
 
 	return container.NewScroll(allGroupsContent)
@@ -298,84 +435,240 @@ func (t *localLauncherTool) createGridView() fyne.CanvasObject {
 func (t *localLauncherTool) createToolButton(index int) fyne.CanvasObject {
 	conf := t.configs[index]
 	toolBtn := newRightClickableButton(conf.Name, t.getIconResource(conf))
-	toolBtn.OnTapped = func() {
-		log.Printf("正在启动: %s (%s)", conf.Path, conf.Type)
-		var err error
-		switch conf.Type {
-		case AppTool:
-			var cmd *exec.Cmd
-			if runtime.GOOS == "windows" {
-				cmd = exec.Command("cmd", "/C", "start", "", conf.Path)
-			} else {
-				cmd = exec.Command(conf.Path)
-				cmd.Dir = filepath.Dir(conf.Path)
-			}
-			err = cmd.Start()
-		case WebTool:
-			u, parseErr := url.Parse(conf.Path)
-			if parseErr != nil {
-				err = parseErr
-				break
-			}
-			err = fyne.CurrentApp().OpenURL(u)
-		default:
-			err = errors.New("未知的工具类型: " + string(conf.Type))
-		}
-		if err != nil {
-			log.Printf("启动 %s 失败: %v", conf.Name, err)
-			dialog.ShowError(err, t.win)
-		}
-	}
+	toolBtn.OnTapped = func() { t.launch(index) }
+	toolBtn.OnDragged = func(ev *fyne.DragEvent) { t.onDragged(index, ev) }
+	toolBtn.OnDragEnd = t.onDragEnd
+	proc := processes[conf.ID]
+	targetErr := conf.checkTarget()
 	toolBtn.OnTappedSecondary = func(pe *fyne.PointEvent) {
 		editItem := fyne.NewMenuItem("编辑", func() { t.showEditDialog(index) })
 		deleteItem := fyne.NewMenuItem("删除", func() { t.showDeleteDialog(index) })
-		popup := widget.NewPopUpMenu(fyne.NewMenu("", editItem, fyne.NewMenuItemSeparator(), deleteItem), t.win.Canvas())
+		favoriteItem := fyne.NewMenuItem("加入托盘收藏", func() { t.toggleFavorite(index) })
+		if conf.Favorite {
+			favoriteItem.Label = "取消托盘收藏"
+		}
+		items := []*fyne.MenuItem{editItem, favoriteItem}
+		if targetErr != nil {
+			problem := fyne.NewMenuItem("⚠ "+targetErr.Error(), nil)
+			problem.Disabled = true
+			items = append([]*fyne.MenuItem{problem, fyne.NewMenuItemSeparator()}, items...)
+		}
+		if iconCacheKey(conf) != "" && conf.Icon == "" {
+			items = append(items, fyne.NewMenuItem("刷新图标", func() { t.refreshIcons([]int{index}, true) }))
+		}
+		if proc != nil {
+			items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("查看日志", func() { showLog(conf.ID) }))
+			if proc.running {
+				items = append(items, fyne.NewMenuItem("停止", proc.stop))
+			}
+			items = append(items, fyne.NewMenuItem("重新启动", func() { t.restart(conf.ID) }))
+		}
+		items = append(items, fyne.NewMenuItemSeparator(), deleteItem)
+		popup := widget.NewPopUpMenu(fyne.NewMenu("", items...), t.win.Canvas())
 		popup.ShowAtPosition(pe.AbsolutePosition)
 	}
 
 	nameLabel := widget.NewLabel(conf.Name)
+	// 运行中的条目高亮显示，异常退出的显示为红色，直到再次启动；目标在本机上不存在的显示为警告
+	switch {
+	case proc != nil && proc.running:
+		toolBtn.Importance = widget.SuccessImportance
+		nameLabel.SetText("● " + conf.Name)
+	case proc != nil && proc.err != nil && !proc.stopped:
+		toolBtn.Importance = widget.DangerImportance
+		nameLabel.SetText("✕ " + conf.Name)
+	case targetErr != nil:
+		toolBtn.Importance = widget.WarningImportance
+		nameLabel.SetText("⚠ " + conf.Name)
+	}
 	nameLabel.Wrapping = fyne.TextWrapWord
 	nameLabel.Alignment = fyne.TextAlignCenter
 	return container.NewVBox(toolBtn, nameLabel)
 }
 
+func (t *localLauncherTool) toggleFavorite(index int) {
+	t.configs[index].Favorite = !t.configs[index].Favorite
+	t.saveConfig()
+	t.refreshUI()
+}
+
 func (t *localLauncherTool) showEditDialog(index int) {
+	var editing ToolConfig
+	if index >= 0 {
+		editing = t.configs[index]
+	}
 	pathEntry := widget.NewEntry()
 	nameEntry := widget.NewEntry()
 	iconEntry := widget.NewEntry()
 	groupEntry := widget.NewEntry()
 	groupEntry.SetPlaceHolder("例如: 开发工具, 娱乐...")
-
+	hotkeyEntry := widget.NewEntry()
+	hotkeyEntry.SetPlaceHolder("可选，例如: Ctrl+Alt+T")
+
+	// 本地软件和脚本的启动选项
+	argsEntry := widget.NewEntry()
+	argsEntry.SetPlaceHolder(`例如: --profile "My Profile"`)
+	workDirEntry := widget.NewEntry()
+	workDirEntry.SetPlaceHolder("默认为程序所在的文件夹")
+	envEntry := widget.NewMultiLineEntry()
+	envEntry.SetPlaceHolder("每行一个 KEY=VALUE")
+	envEntry.SetMinRowsVisible(3)
+	elevatedCheck := widget.NewCheck("以管理员身份运行", nil)
+	previewLabel := widget.NewLabel("")
+	previewLabel.Wrapping = fyne.TextWrapBreak
+	previewLabel.TextStyle.Monospace = true
+	workDirPickerBtn := widget.NewButton("选择...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			workDirEntry.SetText(portablePath(uri.Path()))
+		}, t.win)
+	})
+	appOptions := widget.NewForm(
+		widget.NewFormItem("参数", argsEntry),
+		widget.NewFormItem("工作目录", container.NewBorder(nil, nil, nil, workDirPickerBtn, workDirEntry)),
+		widget.NewFormItem("环境变量", envEntry),
+		widget.NewFormItem("", elevatedCheck),
+		widget.NewFormItem("命令预览", previewLabel),
+	)
+
+	// 启动组合的步骤，可以引用除自己以外的所有条目
+	steps := newChainEditor(t.chainOptions(index), editing.Steps)
+	parallelCheck := widget.NewCheck("同时启动 (延迟从组合开始时计算)", nil)
+	parallelCheck.SetChecked(editing.Parallel)
+	chainOptions := widget.NewForm(
+		widget.NewFormItem("步骤", steps.Widget()),
+		widget.NewFormItem("", parallelCheck),
+	)
+
+	var toolType ToolType
 	pathPickerBtn := widget.NewButton("选择...", func() {
+		onPicked := func(path string) {
+			pathEntry.SetText(portablePath(path))
+			if nameEntry.Text == "" {
+				nameEntry.SetText(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+			}
+		}
+		if toolType == FolderTool {
+			dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+				if err != nil || uri == nil {
+					return
+				}
+				onPicked(uri.Path())
+			}, t.win)
+			return
+		}
 		d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 			if err != nil || reader == nil {
 				return
 			}
 			defer reader.Close()
-			pathEntry.SetText(reader.URI().Path())
-			if nameEntry.Text == "" {
-				nameEntry.SetText(strings.TrimSuffix(filepath.Base(reader.URI().Path()), filepath.Ext(reader.URI().Path())))
-			}
+			onPicked(reader.URI().Path())
 		}, t.win)
+		if toolType == ScriptTool {
+			d.SetFilter(storage.NewExtensionFileFilter([]string{".sh", ".bash", ".bat", ".cmd", ".ps1", ".py"}))
+		}
 		d.Show()
 	})
 	pathContainer := container.NewBorder(nil, nil, nil, pathPickerBtn, pathEntry)
 
-	typeSelect := widget.NewSelect([]string{"本地软件", "网站"}, func(s string) {
-		if s == "本地软件" {
+	// 其他系统上的路径，配置文件在多台电脑之间同步时使用
+	osPathsEntry := widget.NewMultiLineEntry()
+	osPathsEntry.SetPlaceHolder("可选，每行一个 系统=路径，例如:\nwindows=C:/Program Files/App/app.exe\nlinux=/usr/bin/app")
+	osPathsEntry.SetMinRowsVisible(2)
+	pathHint := widget.NewLabel("路径可以使用 ${HOME}、${APPDIR}、${ENV:NAME}，相对路径相对于工具箱所在的文件夹")
+	pathHint.Wrapping = fyne.TextWrapWord
+	portableOptions := widget.NewForm(
+		widget.NewFormItem("其他系统", osPathsEntry),
+		widget.NewFormItem("", pathHint),
+	)
+
+	var typeLabels []string
+	for _, tt := range toolTypes {
+		typeLabels = append(typeLabels, toolTypeLabels[tt])
+	}
+	typeSelect := widget.NewSelect(typeLabels, nil)
+	var pathItem *widget.FormItem
+	var mainForm *widget.Form
+
+	// launchOptions 从输入框收集启动选项，预览和保存共用
+	launchOptions := func() (ToolConfig, error) {
+		env, err := parseEnvText(envEntry.Text)
+		conf := ToolConfig{Type: toolType, Path: pathEntry.Text, Args: argsEntry.Text, WorkDir: workDirEntry.Text, Env: env, Elevated: elevatedCheck.Checked && toolType == AppTool}
+		if err == nil {
+			conf.Paths, err = parseOSPaths(osPathsEntry.Text)
+		}
+		if err == nil {
+			_, err = splitArgs(argsEntry.Text)
+		}
+		if err == nil && conf.Elevated && len(env) > 0 && runtime.GOOS == "windows" {
+			err = errElevatedEnv
+		}
+		return conf, err
+	}
+	updatePreview := func() {
+		conf, err := launchOptions()
+		if err != nil {
+			previewLabel.SetText("错误: " + err.Error())
+			return
+		}
+		previewLabel.SetText(commandPreview(conf))
+	}
+	for _, e := range []*widget.Entry{pathEntry, osPathsEntry, argsEntry, workDirEntry, envEntry} {
+		e.OnChanged = func(string) { updatePreview() }
+	}
+	elevatedCheck.OnChanged = func(bool) { updatePreview() }
+
+	typeSelect.OnChanged = func(s string) {
+		toolType = AppTool
+		for tt, label := range toolTypeLabels {
+			if label == s {
+				toolType = tt
+			}
+		}
+		pathPickerBtn.Show()
+		appOptions.Hide()
+		chainOptions.Hide()
+		portableOptions.Show()
+		elevatedCheck.Show()
+		pathItem.Text = "路径"
+		switch toolType {
+		case AppTool:
 			pathEntry.SetPlaceHolder("点击右侧按钮选择可执行文件...")
 			nameEntry.SetPlaceHolder("软件名称")
-			pathPickerBtn.Show()
-		} else {
+			appOptions.Show()
+		case WebTool:
+			pathItem.Text = "网址"
 			pathEntry.SetPlaceHolder("请输入完整的网址, 例如 https://www.google.com")
 			nameEntry.SetPlaceHolder("网站名称")
 			pathPickerBtn.Hide()
+			portableOptions.Hide()
 			if nameEntry.Text == "" {
 				nameEntry.SetText("新网站")
 			}
+		case FolderTool:
+			pathEntry.SetPlaceHolder("点击右侧按钮选择文件夹...")
+			nameEntry.SetPlaceHolder("文件夹名称")
+		case FileTool:
+			pathEntry.SetPlaceHolder("点击右侧按钮选择文件，将用默认程序打开...")
+			nameEntry.SetPlaceHolder("文件名称")
+		case ScriptTool:
+			pathEntry.SetPlaceHolder("点击右侧按钮选择脚本 (.sh, .bat, .ps1, .py...)")
+			nameEntry.SetPlaceHolder("脚本名称")
+			appOptions.Show()
+			elevatedCheck.Hide()
+		case ChainTool:
+			pathItem.Text = "说明"
+			pathEntry.SetPlaceHolder("可选，例如: 打开开发环境")
+			nameEntry.SetPlaceHolder("组合名称")
+			pathPickerBtn.Hide()
+			portableOptions.Hide()
+			chainOptions.Show()
 		}
-		pathContainer.Refresh()
-	})
+		updatePreview()
+		mainForm.Refresh()
+	}
 
 	iconPickerBtn := widget.NewButton("选择...", func() {
 		d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -383,57 +676,97 @@ func (t *localLauncherTool) showEditDialog(index int) {
 				return
 			}
 			defer reader.Close()
-			iconEntry.SetText(reader.URI().Path())
+			iconEntry.SetText(portablePath(reader.URI().Path()))
 		}, t.win)
 		d.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".jpg", ".jpeg", ".ico", ".svg"}))
 		d.Show()
 	})
 
+	pathItem = widget.NewFormItem("路径", pathContainer)
+	mainForm = widget.NewForm(
+		widget.NewFormItem("类型", typeSelect),
+		widget.NewFormItem("分组名称", groupEntry),
+		pathItem,
+		widget.NewFormItem("名称", nameEntry),
+		widget.NewFormItem("图标", container.NewBorder(nil, nil, nil, iconPickerBtn, iconEntry)),
+		widget.NewFormItem("快捷键", hotkeyEntry),
+	)
+
 	dialogTitle := "添加新条目"
 	if index >= 0 {
-		conf := t.configs[index]
 		dialogTitle = "编辑条目"
-		pathEntry.SetText(conf.Path)
-		nameEntry.SetText(conf.Name)
-		iconEntry.SetText(conf.Icon)
-		groupEntry.SetText(conf.Group)
-		if conf.Type == WebTool {
-			typeSelect.SetSelected("网站")
+		pathEntry.SetText(editing.Path)
+		nameEntry.SetText(editing.Name)
+		iconEntry.SetText(editing.Icon)
+		hotkeyEntry.SetText(editing.Hotkey)
+		groupEntry.SetText(editing.Group)
+		argsEntry.SetText(editing.Args)
+		osPathsEntry.SetText(formatOSPaths(editing.Paths))
+		workDirEntry.SetText(editing.WorkDir)
+		envEntry.SetText(strings.Join(editing.Env, "\n"))
+		elevatedCheck.SetChecked(editing.Elevated)
+		if label, ok := toolTypeLabels[editing.Type]; ok {
+			typeSelect.SetSelected(label)
 		} else {
-			typeSelect.SetSelected("本地软件")
+			typeSelect.SetSelected(toolTypeLabels[AppTool])
 		}
 	} else {
-		typeSelect.SetSelected("本地软件")
+		typeSelect.SetSelected(toolTypeLabels[AppTool])
 	}
 
-	formItems := []*widget.FormItem{
-		widget.NewFormItem("类型", typeSelect),
-		widget.NewFormItem("分组名称", groupEntry),
-		widget.NewFormItem("路径/网址", pathContainer),
-		widget.NewFormItem("名称", nameEntry),
-		widget.NewFormItem("图标", container.NewBorder(nil, nil, nil, iconPickerBtn, iconEntry)),
-	}
-
-	confirmDialog := dialog.NewForm(dialogTitle, "保存", "取消", formItems, func(ok bool) {
+	content := container.NewVBox(mainForm, portableOptions, appOptions, chainOptions)
+	confirmDialog := dialog.NewCustomConfirm(dialogTitle, "保存", "取消", container.NewVScroll(content), func(ok bool) {
 		if !ok {
 			return
 		}
-		if pathEntry.Text == "" || nameEntry.Text == "" {
+		if nameEntry.Text == "" || (pathEntry.Text == "" && toolType != ChainTool) {
 			dialog.ShowError(errors.New("路径/网址和名称不能为空"), t.win)
 			return
 		}
-
-		var toolType ToolType
-		if typeSelect.Selected == "网站" {
-			toolType = WebTool
-		} else {
-			toolType = AppTool
+		newConfig := ToolConfig{Type: toolType, Path: pathEntry.Text, Name: nameEntry.Text, Icon: iconEntry.Text}
+		if toolType != WebTool && toolType != ChainTool {
+			paths, err := parseOSPaths(osPathsEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			newConfig.Paths = paths
+		}
+		newConfig.ID = editing.ID // 新条目在保存时分配 ID
+		newConfig.LaunchCount, newConfig.LastLaunch, newConfig.Favorite = editing.LaunchCount, editing.LastLaunch, editing.Favorite
+		if s := strings.TrimSpace(hotkeyEntry.Text); s != "" {
+			hk, err := parseHotkey(s)
+			if err == nil {
+				err = t.hotkeyConflict(hk, index)
+			}
+			if err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			newConfig.Hotkey = hk.String()
+		}
+		switch toolType {
+		case AppTool, ScriptTool:
+			options, err := launchOptions()
+			if err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			newConfig.Args, newConfig.WorkDir, newConfig.Env, newConfig.Elevated = options.Args, options.WorkDir, options.Env, options.Elevated
+		case ChainTool:
+			chainSteps, err := steps.Steps()
+			if err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			newConfig.Steps, newConfig.Parallel = chainSteps, parallelCheck.Checked
 		}
 
 		groupName := groupEntry.Text
 		if groupName == "" {
 			groupName = defaultGroup
 		}
+		newConfig.Group = groupName
 
 		isNewGroup := true
 		for _, g := range t.groupOrder {
@@ -452,7 +785,6 @@ func (t *localLauncherTool) showEditDialog(index int) {
 			}
 		}
 
-		newConfig := ToolConfig{Type: toolType, Path: pathEntry.Text, Name: nameEntry.Text, Icon: iconEntry.Text, Group: groupName}
 		if index == -1 {
 			t.configs = append(t.configs, newConfig)
 		} else {
@@ -474,17 +806,22 @@ func (t *localLauncherTool) showEditDialog(index int) {
 		t.saveConfig()
 		t.refreshUI()
 	}, t.win)
-	confirmDialog.Resize(fyne.NewSize(500, 300))
+	confirmDialog.Resize(fyne.NewSize(560, 560))
 	confirmDialog.Show()
 }
 
 func (t *localLauncherTool) showDeleteDialog(index int) {
 	conf := t.configs[index]
-	dialog.ShowConfirm("确认删除", "你确定要删除 '"+conf.Name+"' 吗？此操作无法撤销。", func(ok bool) {
+	msg := "你确定要删除 '" + conf.Name + "' 吗？此操作无法撤销。"
+	if chains := t.chainsUsing(conf.ID); len(chains) > 0 {
+		msg += "\n启动组合 " + strings.Join(chains, "、") + " 引用了它，删除后这些组合中的对应步骤会失败。"
+	}
+	dialog.ShowConfirm("确认删除", msg, func(ok bool) {
 		if !ok {
 			return
 		}
 		groupOfDeletedItem := t.configs[index].Group
+		forgetProcess(conf.ID)
 		t.configs = append(t.configs[:index], t.configs[index+1:]...)
 
 		isGroupEmpty := true
@@ -510,14 +847,31 @@ func (t *localLauncherTool) showDeleteDialog(index int) {
 
 func (t *localLauncherTool) getIconResource(conf ToolConfig) fyne.Resource {
 	if conf.Icon != "" {
-		res, err := fyne.LoadResourceFromPath(conf.Icon)
+		icon, err := expandPath(conf.Icon, false)
 		if err == nil {
-			return res
+			var res fyne.Resource
+			if res, err = loadIcon(icon); err == nil {
+				return res
+			}
 		}
 		log.Printf("加载图标 %s 失败: %v, 使用默认图标", conf.Icon, err)
 	}
-	if conf.Type == WebTool {
+	if path, ok := cachedIconPath(conf); ok {
+		if res, err := loadIcon(path); err == nil {
+			return res
+		}
+	}
+	switch conf.Type {
+	case WebTool:
 		return theme.SearchIcon()
+	case FolderTool:
+		return theme.FolderIcon()
+	case FileTool:
+		return theme.FileIcon()
+	case ScriptTool:
+		return theme.FileTextIcon()
+	case ChainTool:
+		return theme.MediaFastForwardIcon()
 	}
 	return theme.ComputerIcon()
 }
@@ -525,6 +879,8 @@ func (t *localLauncherTool) getIconResource(conf ToolConfig) fyne.Resource {
 type rightClickableButton struct {
 	widget.Button
 	OnTappedSecondary func(pe *fyne.PointEvent)
+	OnDragged         func(ev *fyne.DragEvent)
+	OnDragEnd         func()
 }
 
 func newRightClickableButton(text string, icon fyne.Resource) *rightClickableButton {
@@ -538,3 +894,15 @@ func (b *rightClickableButton) TappedSecondary(pe *fyne.PointEvent) {
 		b.OnTappedSecondary(pe)
 	}
 }
+
+func (b *rightClickableButton) Dragged(ev *fyne.DragEvent) {
+	if b.OnDragged != nil {
+		b.OnDragged(ev)
+	}
+}
+
+func (b *rightClickableButton) DragEnd() {
+	if b.OnDragEnd != nil {
+		b.OnDragEnd()
+	}
+}
@@ -0,0 +1,124 @@
+package locallauncher
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// activeTool 是当前打开的启动器标签页。快速启动面板优先使用它，
+// 以免两份内存中的配置互相覆盖启动统计。
+var activeTool *localLauncherTool
+
+// paletteMaxResults 是快速启动面板最多显示的条目数
+const paletteMaxResults = 50
+
+// InstallPalette 在窗口上注册 Ctrl+Space 快捷键，打开快速启动面板。
+// 输入框获得焦点时快捷键由输入框处理，不会打开面板。
+func InstallPalette(win fyne.Window) {
+	win.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeySpace, Modifier: fyne.KeyModifierControl}, func(fyne.Shortcut) {
+		ShowPalette(win)
+	})
+}
+
+// ShowPalette 显示快速启动面板：输入即模糊搜索，上下键选择，回车启动，Esc 关闭
+func ShowPalette(win fyne.Window) {
+	t := launcherFor(win)
+
+	results := t.search("")
+	selected := 0
+	var popup *widget.PopUp
+	launchSelected := func() {
+		if selected < len(results) {
+			popup.Hide()
+			t.launch(results[selected])
+		}
+	}
+
+	list := widget.NewList(
+		func() int { return min(len(results), paletteMaxResults) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewIcon(nil), nil, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			conf := t.configs[results[id]]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(conf.Name + "    " + conf.Group + " · " + conf.Path)
+			row.Objects[1].(*widget.Icon).SetResource(t.getIconResource(conf))
+		},
+	)
+	// 键盘移动选择时不启动，鼠标点击时直接启动
+	navigating := false
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		if !navigating {
+			launchSelected()
+		}
+	}
+	move := func(delta int) {
+		n := min(len(results), paletteMaxResults)
+		if n == 0 {
+			return
+		}
+		selected = (selected + delta + n) % n
+		navigating = true
+		list.Select(selected)
+		navigating = false
+	}
+
+	entry := newPaletteEntry()
+	entry.SetPlaceHolder("输入名称、拼音首字母、分组或路径...")
+	entry.OnChanged = func(query string) {
+		results = t.search(query)
+		selected = 0
+		list.UnselectAll()
+		list.Refresh()
+		if len(results) > 0 {
+			move(0)
+		}
+	}
+	entry.OnSubmitted = func(string) { launchSelected() }
+	entry.onKey = func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyUp:
+			move(-1)
+		case fyne.KeyDown:
+			move(1)
+		case fyne.KeyEscape:
+			popup.Hide()
+		default:
+			return false
+		}
+		return true
+	}
+
+	content := container.NewBorder(entry, nil, nil, nil, list)
+	popup = widget.NewModalPopUp(content, win.Canvas())
+	size := win.Canvas().Size()
+	popup.Resize(fyne.NewSize(min(640, size.Width*0.9), min(420, size.Height*0.8)))
+	popup.Show()
+	if len(results) > 0 {
+		move(0)
+	}
+	win.Canvas().Focus(entry)
+}
+
+// paletteEntry 把上下键和 Esc 交给快速启动面板处理
+type paletteEntry struct {
+	widget.Entry
+	onKey func(fyne.KeyName) bool
+}
+
+func newPaletteEntry() *paletteEntry {
+	e := &paletteEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *paletteEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey != nil && e.onKey(key.Name) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
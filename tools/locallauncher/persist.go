@@ -0,0 +1,139 @@
+package locallauncher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 配置文件先写入同一文件夹中的临时文件，再重命名替换，写入过程中崩溃不会留下不完整的文件。
+// 覆盖之前，如果距离上次备份已超过 backupInterval，把当前文件复制到 data/locallauncher_backups，
+// 最多保留 maxBackups 份。读取时发现文件损坏，会从最近一份能解析的备份恢复。
+
+const (
+	backupDirName    = "locallauncher_backups"
+	backupTimeLayout = "20060102-150405"
+	backupInterval   = time.Hour
+	maxBackups       = 10
+)
+
+func backupDir() string { return filepath.Join(configDir, backupDirName) }
+
+// writeFileAtomic 写入临时文件并同步到磁盘后，重命名为 path
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// parseSaveData 解析配置文件，兼容只包含条目数组的旧格式 (legacy 为 true)
+func parseSaveData(data []byte) (saveData SaveData, legacy bool, err error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return SaveData{}, false, errors.New("文件为空")
+	}
+	if err = json.Unmarshal(data, &saveData); err == nil {
+		return saveData, false, nil
+	}
+	if json.Unmarshal(data, &saveData.Configs) == nil {
+		return saveData, true, nil
+	}
+	return SaveData{}, false, err
+}
+
+// listBackups 返回所有备份文件，最新的在前
+func listBackups() []string {
+	entries, err := os.ReadDir(backupDir())
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "locallauncher-") && strings.HasSuffix(e.Name(), ".json") {
+			backups = append(backups, filepath.Join(backupDir(), e.Name()))
+		}
+	}
+	// 文件名中的时间戳按字典序即按时间排序
+	slices.Sort(backups)
+	slices.Reverse(backups)
+	return backups
+}
+
+// backupConfig 在覆盖配置文件之前备份它。只备份能正常解析的文件，并删除超出数量的旧备份。
+func backupConfig(path string, now time.Time) error {
+	backups := listBackups()
+	if len(backups) > 0 {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(backups[0]), "locallauncher-"), ".json")
+		if last, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local); err == nil && now.Sub(last) < backupInterval {
+			return nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, _, err := parseSaveData(data); err != nil {
+		return nil // 不备份损坏的文件，以免覆盖掉可用的备份
+	}
+	if err := os.MkdirAll(backupDir(), 0755); err != nil {
+		return err
+	}
+	name := filepath.Join(backupDir(), "locallauncher-"+now.Format(backupTimeLayout)+".json")
+	if err := writeFileAtomic(name, data); err != nil {
+		return err
+	}
+	backups = append([]string{name}, backups...)
+	for _, old := range backups[min(len(backups), maxBackups):] {
+		os.Remove(old)
+	}
+	return nil
+}
+
+// recoverConfig 处理损坏的配置文件：把它改名保留下来，再从最近一份能解析的备份恢复。
+// 返回恢复出的数据和所用的备份文件，没有可用的备份时 from 为空。
+func recoverConfig(path string, now time.Time) (saveData SaveData, from string, err error) {
+	corrupt := path + ".corrupt-" + now.Format(backupTimeLayout)
+	if err := os.Rename(path, corrupt); err != nil {
+		return SaveData{}, "", fmt.Errorf("无法移走损坏的配置文件: %v", err)
+	}
+	for _, b := range listBackups() {
+		data, err := os.ReadFile(b)
+		if err != nil {
+			continue
+		}
+		if saveData, _, err = parseSaveData(data); err != nil {
+			continue
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return saveData, b, fmt.Errorf("已从备份读取配置，但写回配置文件失败: %v", err)
+		}
+		return saveData, b, nil
+	}
+	return SaveData{}, "", nil
+}
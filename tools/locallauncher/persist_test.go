@@ -0,0 +1,239 @@
+package locallauncher
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inTempConfigDir 让 configDir ("./data") 指向一个空的临时文件夹，返回配置文件的路径
+func inTempConfigDir(t *testing.T) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(configDir, configFileName)
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func backupPath(at time.Time) string {
+	return filepath.Join(backupDir(), "locallauncher-"+at.Format(backupTimeLayout)+".json")
+}
+
+func configJSON(name string) string {
+	return `{"configs":[{"name":"` + name + `","type":"web","path":"https://x/"}]}`
+}
+
+func TestParseSaveData(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantLegacy bool
+		wantNames  []string
+		wantErr    bool
+	}{
+		{name: "当前格式", data: `{"configs":[{"name":"a"}],"group_order":["g"]}`, wantNames: []string{"a"}},
+		{name: "旧版数组", data: `[{"name":"a"},{"name":"b"}]`, wantLegacy: true, wantNames: []string{"a", "b"}},
+		{name: "空文件", data: " \n", wantErr: true},
+		{name: "截断的 JSON", data: `{"configs":[{"name":`, wantErr: true},
+		{name: "不是 JSON", data: "\x00\x00\x00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, legacy, err := parseSaveData([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSaveData() = %+v, 期望出错", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSaveData() 出错: %v", err)
+			}
+			var names []string
+			for _, c := range got.Configs {
+				names = append(names, c.Name)
+			}
+			if legacy != tt.wantLegacy || !slices.Equal(names, tt.wantNames) {
+				t.Errorf("parseSaveData() = %v, legacy=%v", names, legacy)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("内容 = %q, 期望 %q", data, content)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("留下了临时文件: %v", entries)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "x.json"), nil); err == nil {
+		t.Error("文件夹不存在时期望出错")
+	}
+}
+
+func TestBackupConfig(t *testing.T) {
+	path := inTempConfigDir(t)
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.Local)
+
+	// 配置文件还不存在
+	if err := backupConfig(path, start); err != nil || len(listBackups()) != 0 {
+		t.Fatalf("没有配置文件时: %v, %v", err, listBackups())
+	}
+
+	// 损坏的文件不备份
+	writeTestFile(t, path, `{"configs":`)
+	if err := backupConfig(path, start); err != nil || len(listBackups()) != 0 {
+		t.Fatalf("损坏的配置文件被备份: %v, %v", err, listBackups())
+	}
+
+	writeTestFile(t, path, configJSON("v1"))
+	if err := backupConfig(path, start); err != nil {
+		t.Fatal(err)
+	}
+	if got := listBackups(); !slices.Equal(got, []string{backupPath(start)}) {
+		t.Fatalf("备份 = %v", got)
+	}
+	if data, _ := os.ReadFile(backupPath(start)); string(data) != configJSON("v1") {
+		t.Errorf("备份的内容 = %s", data)
+	}
+
+	// 距离上次备份不到 backupInterval 时不再备份
+	writeTestFile(t, path, configJSON("v2"))
+	if err := backupConfig(path, start.Add(backupInterval-time.Second)); err != nil || len(listBackups()) != 1 {
+		t.Fatalf("间隔内又备份了: %v, %v", err, listBackups())
+	}
+
+	// 超过间隔后备份，最多保留 maxBackups 份，删除最旧的
+	var want []string
+	for i := 1; i <= maxBackups+2; i++ {
+		at := start.Add(time.Duration(i) * backupInterval)
+		writeTestFile(t, path, configJSON(at.Format(backupTimeLayout)))
+		if err := backupConfig(path, at); err != nil {
+			t.Fatal(err)
+		}
+		want = append([]string{backupPath(at)}, want...)
+	}
+	want = want[:maxBackups]
+	if got := listBackups(); !slices.Equal(got, want) {
+		t.Errorf("备份 =\n%v\n期望\n%v", got, want)
+	}
+
+	// 备份文件夹中的其他文件不受影响
+	other := filepath.Join(backupDir(), "notes.txt")
+	writeTestFile(t, other, "keep")
+	at := start.Add(time.Duration(maxBackups+3) * backupInterval)
+	if err := backupConfig(path, at); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("其他文件被删除: %v", err)
+	}
+	if got := listBackups(); len(got) != maxBackups || got[0] != backupPath(at) {
+		t.Errorf("备份 = %v", got)
+	}
+}
+
+func TestRecoverConfig(t *testing.T) {
+	now := time.Date(2025, 3, 2, 9, 30, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		backups   map[time.Duration]string // 距 now 的时间 -> 内容
+		wantFrom  time.Duration            // 期望使用的备份，-1 表示没有可用的备份
+		wantNames []string
+	}{
+		{
+			name:      "使用最近的备份",
+			backups:   map[time.Duration]string{3 * time.Hour: configJSON("old"), time.Hour: configJSON("new")},
+			wantFrom:  time.Hour,
+			wantNames: []string{"new"},
+		},
+		{
+			name:      "跳过损坏的备份",
+			backups:   map[time.Duration]string{3 * time.Hour: configJSON("old"), 2 * time.Hour: "", time.Hour: `{"configs":[`},
+			wantFrom:  3 * time.Hour,
+			wantNames: []string{"old"},
+		},
+		{
+			name:      "旧版数组格式的备份",
+			backups:   map[time.Duration]string{time.Hour: `[{"name":"legacy"}]`},
+			wantFrom:  time.Hour,
+			wantNames: []string{"legacy"},
+		},
+		{name: "没有备份", wantFrom: -1},
+		{name: "没有可用的备份", backups: map[time.Duration]string{time.Hour: "garbage"}, wantFrom: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := inTempConfigDir(t)
+			const corrupt = `{"configs":[{"name":"broken"`
+			writeTestFile(t, path, corrupt)
+			for ago, content := range tt.backups {
+				writeTestFile(t, backupPath(now.Add(-ago)), content)
+			}
+
+			saveData, from, err := recoverConfig(path, now)
+			if err != nil {
+				t.Fatalf("recoverConfig() 出错: %v", err)
+			}
+			// 损坏的文件总是改名保留
+			if data, err := os.ReadFile(path + ".corrupt-" + now.Format(backupTimeLayout)); err != nil || string(data) != corrupt {
+				t.Errorf("损坏的文件没有保留: %v", err)
+			}
+
+			if tt.wantFrom < 0 {
+				if from != "" || len(saveData.Configs) != 0 {
+					t.Errorf("recoverConfig() = %+v, %q, 期望没有可用的备份", saveData, from)
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("没有备份时不应写回配置文件: %v", err)
+				}
+				return
+			}
+			if want := backupPath(now.Add(-tt.wantFrom)); from != want {
+				t.Errorf("from = %s, 期望 %s", from, want)
+			}
+			var names []string
+			for _, c := range saveData.Configs {
+				names = append(names, c.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("恢复的条目 = %v, 期望 %v", names, tt.wantNames)
+			}
+			// 备份的内容被写回配置文件
+			restored, _ := os.ReadFile(path)
+			if backup, _ := os.ReadFile(from); string(restored) != string(backup) {
+				t.Errorf("配置文件 = %s, 期望与备份相同", restored)
+			}
+		})
+	}
+
+	t.Run("配置文件不存在", func(t *testing.T) {
+		path := inTempConfigDir(t)
+		if _, _, err := recoverConfig(path, now); err == nil || !strings.Contains(err.Error(), "无法移走") {
+			t.Errorf("recoverConfig() = %v, 期望出错", err)
+		}
+	})
+}
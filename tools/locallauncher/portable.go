@@ -0,0 +1,190 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 为了让配置文件可以在多台电脑之间同步，Path、Icon 和 WorkDir 中可以使用变量：
+//   ${HOME}      用户主目录
+//   ${APPDIR}    工具箱程序所在的文件夹
+//   ${ENV:NAME}  环境变量 NAME
+// 相对路径相对于 ${APPDIR}。ToolConfig.Paths 可以为其他系统指定不同的路径，例如 {"windows": "C:/..."}。
+
+// portableOSes 是编辑对话框中可以单独设置路径的系统，键与 runtime.GOOS 相同
+var portableOSes = []string{"windows", "darwin", "linux"}
+
+var pathVarRe = regexp.MustCompile(`\$\{([A-Za-z_]+)(?::([^}]*))?\}`)
+
+// appDir 返回工具箱程序所在的文件夹
+var appDir = sync.OnceValue(func() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe)
+})
+
+// expandVars 替换路径或网址中的变量，未定义的变量或环境变量返回错误
+func expandVars(p string) (string, error) {
+	var errs []error
+	expanded := pathVarRe.ReplaceAllStringFunc(p, func(m string) string {
+		sub := pathVarRe.FindStringSubmatch(m)
+		switch {
+		case sub[1] == "HOME" && sub[2] == "":
+			home, err := os.UserHomeDir()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			return home
+		case sub[1] == "APPDIR" && sub[2] == "":
+			return appDir()
+		case sub[1] == "ENV" && sub[2] != "":
+			value, ok := os.LookupEnv(sub[2])
+			if !ok {
+				errs = append(errs, fmt.Errorf("环境变量 %s 未设置", sub[2]))
+			}
+			return value
+		}
+		errs = append(errs, fmt.Errorf("未知的变量 %s", m))
+		return m
+	})
+	return expanded, errors.Join(errs...)
+}
+
+// expandPath 替换路径中的变量，并把相对路径转换为相对于 ${APPDIR} 的绝对路径。
+// bare 为 true 时不含路径分隔符的名称保持不变，用于在 PATH 中查找的命令。
+func expandPath(p string, bare bool) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	expanded, err := expandVars(p)
+	if err != nil {
+		return "", err
+	}
+	expanded = filepath.FromSlash(expanded)
+	if bare && !strings.ContainsAny(expanded, `/\`) {
+		return expanded, nil
+	}
+	if !filepath.IsAbs(expanded) {
+		expanded = filepath.Join(appDir(), expanded)
+	}
+	return expanded, nil
+}
+
+// portablePath 把选择的绝对路径改写为使用 ${APPDIR} 或 ${HOME} 的形式，分隔符统一为 /
+func portablePath(p string) string {
+	home, _ := os.UserHomeDir()
+	for _, base := range []struct{ name, dir string }{{"${APPDIR}", appDir()}, {"${HOME}", home}} {
+		if base.dir == "" || base.dir == "." {
+			continue
+		}
+		rel, err := filepath.Rel(base.dir, p)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if rel == "." {
+				return base.name
+			}
+			return base.name + "/" + filepath.ToSlash(rel)
+		}
+	}
+	return p
+}
+
+// pathForOS 返回条目在当前系统上使用的路径 (替换变量之前)
+func (c ToolConfig) pathForOS() string {
+	if p := c.Paths[runtime.GOOS]; p != "" {
+		return p
+	}
+	return c.Path
+}
+
+// resolved 返回在当前系统上实际使用的条目：选择当前系统的路径，并展开 Path、Icon 和 WorkDir 中的变量。
+// 网站只替换变量，启动组合的 Path 是说明文字，保持不变。
+func (c ToolConfig) resolved() (ToolConfig, error) {
+	var err error
+	switch c.Type {
+	case ChainTool:
+		return c, nil
+	case WebTool:
+		c.Path, err = expandVars(c.pathForOS())
+	default:
+		c.Path, err = expandPath(c.pathForOS(), c.Type == AppTool)
+	}
+	if err != nil {
+		return c, err
+	}
+	if c.Icon, err = expandPath(c.Icon, false); err != nil {
+		return c, err
+	}
+	c.WorkDir, err = expandPath(c.WorkDir, false)
+	return c, err
+}
+
+// checkTarget 检查条目指向的目标在本机上是否存在，网站和启动组合总是返回 nil
+func (c ToolConfig) checkTarget() error {
+	if c.Type == WebTool || c.Type == ChainTool {
+		return nil
+	}
+	r, err := c.resolved()
+	if err != nil {
+		return err
+	}
+	if c.Type == AppTool && !strings.ContainsAny(r.Path, `/\`) {
+		_, err = exec.LookPath(r.Path)
+		return err
+	}
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s 不存在", r.Path)
+		}
+		return err
+	}
+	if c.Type == FolderTool && !info.IsDir() {
+		return fmt.Errorf("%s 不是文件夹", r.Path)
+	}
+	return nil
+}
+
+// parseOSPaths 解析编辑对话框中 "系统=路径" 格式的多行文本
+func parseOSPaths(text string) (map[string]string, error) {
+	var paths map[string]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		goos, p, ok := strings.Cut(line, "=")
+		goos, p = strings.ToLower(strings.TrimSpace(goos)), strings.TrimSpace(p)
+		if !ok || p == "" || !slices.Contains(portableOSes, goos) {
+			return nil, fmt.Errorf("无效的系统路径 %q，格式为 系统=路径，系统可以是 %s", line, strings.Join(portableOSes, "、"))
+		}
+		if paths == nil {
+			paths = map[string]string{}
+		}
+		paths[goos] = p
+	}
+	return paths, nil
+}
+
+// formatOSPaths 是 parseOSPaths 的反向操作
+func formatOSPaths(paths map[string]string) string {
+	var lines []string
+	for _, goos := range portableOSes {
+		if p := paths[goos]; p != "" {
+			lines = append(lines, goos+"="+p)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
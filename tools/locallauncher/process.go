@@ -0,0 +1,334 @@
+package locallauncher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 启动器直接启动的程序和脚本会被跟踪：标准输出和标准错误写入 data/locallauncher_logs 下
+// 每个条目的日志文件 (按条目 ID 命名，改名后仍然对应) (进程直接持有文件，工具集退出后程序仍可正常输出)，退出时记录退出码。
+// 通过 cmd /C start 或提权工具启动的程序无法跟踪，只回收启动它们的进程。
+
+const (
+	logDir       = "locallauncher_logs"
+	maxOutputLen = 200_000 // 日志窗口保留的最大字节数，超出后丢弃最早的内容
+	stopTimeout  = 5 * time.Second
+)
+
+// trackedProcess 是某个条目最近一次启动的进程。字段只在 UI 线程中读写。
+type trackedProcess struct {
+	id      string // 条目的 ID
+	name    string // 启动时条目的名称，用于显示
+	cmd     *exec.Cmd
+	logPath string
+	running bool
+	stopped bool  // 用户主动停止，退出时不视为崩溃
+	err     error // 退出结果
+	exited  chan struct{}
+	window  *outputWindow
+}
+
+// processes 按条目 ID 记录最近一次启动的进程，启动器标签页关闭后仍然保留
+var processes = map[string]*trackedProcess{}
+
+func logPathFor(id string) string {
+	return filepath.Join(configDir, logDir, id+".log")
+}
+
+// forgetProcess 在条目被删除时丢弃它的运行记录和日志，正在运行的进程继续运行，只是不再跟踪
+func forgetProcess(id string) {
+	if p := processes[id]; p != nil {
+		if p.running {
+			return
+		}
+		if p.window != nil {
+			p.window.win.Close()
+		}
+		delete(processes, id)
+	}
+	os.Remove(logPathFor(id))
+}
+
+// startTracked 启动命令并开始跟踪，日志文件在每次启动时清空。done 在进程退出时收到退出结果。
+func startTracked(conf ToolConfig, cmd *exec.Cmd) (*trackedProcess, <-chan error, error) {
+	p := &trackedProcess{id: conf.ID, name: conf.Name, cmd: cmd, logPath: logPathFor(conf.ID), exited: make(chan struct{})}
+	if err := os.MkdirAll(filepath.Dir(p.logPath), 0755); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Create(p.logPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close() // 子进程持有自己的副本
+	fmt.Fprintf(f, "[%s] > %s\n", time.Now().Format("2006-01-02 15:04:05"), strings.Join(cmd.Args, " "))
+	cmd.Stdout, cmd.Stderr = f, f
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	p.running = true
+	if old := processes[conf.ID]; old != nil && old.window != nil {
+		p.window = old.window
+		p.window.attach(p)
+	}
+	processes[conf.ID] = p
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		fyne.Do(func() { p.finish(err) })
+		done <- err
+	}()
+	return p, done, nil
+}
+
+// finish 记录进程的退出结果，非主动停止的异常退出会发送系统通知
+func (p *trackedProcess) finish(err error) {
+	p.running = false
+	p.err = err
+	close(p.exited)
+	status := "退出码 0"
+	if err != nil {
+		status = err.Error()
+	}
+	if f, ferr := os.OpenFile(p.logPath, os.O_APPEND|os.O_WRONLY, 0644); ferr == nil {
+		fmt.Fprintf(f, "\n[%s] 进程已结束: %s\n", time.Now().Format("2006-01-02 15:04:05"), status)
+		f.Close()
+	}
+	if err != nil && !p.stopped {
+		log.Printf("%s 异常退出: %v", p.name, err)
+		fyne.CurrentApp().SendNotification(fyne.NewNotification(p.name+" 异常退出", status))
+	}
+	if p.window != nil {
+		p.window.update()
+	}
+	if activeTool != nil {
+		activeTool.refreshUI()
+	}
+}
+
+// stop 请求进程退出，超时后强制结束。Windows 不支持 SIGTERM，直接结束。
+func (p *trackedProcess) stop() {
+	if !p.running {
+		return
+	}
+	p.stopped = true
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		p.cmd.Process.Kill()
+		return
+	}
+	go func() {
+		select {
+		case <-p.exited:
+		case <-time.After(stopTimeout):
+			p.cmd.Process.Kill()
+		}
+	}()
+}
+
+// restart 停止正在运行的进程，等它退出后重新启动条目
+func (t *localLauncherTool) restart(id string) {
+	p := processes[id]
+	relaunch := func() {
+		if i := t.indexByID(id); i >= 0 {
+			t.launch(i)
+		}
+	}
+	if p == nil || !p.running {
+		relaunch()
+		return
+	}
+	p.stop()
+	go func() {
+		<-p.exited
+		fyne.Do(relaunch)
+	}()
+}
+
+// showLog 显示条目最近一次运行的日志窗口
+func showLog(id string) {
+	p := processes[id]
+	if p == nil {
+		return
+	}
+	if p.window != nil {
+		p.window.win.Show()
+		p.window.win.RequestFocus()
+		return
+	}
+	p.window = newOutputWindow(p)
+}
+
+// outputWindow 是跟踪日志文件内容的独立窗口
+type outputWindow struct {
+	proc   *trackedProcess
+	win    fyne.Window
+	text   *widget.Entry
+	status *widget.Label
+	stop   *widget.Button
+	offset int64
+	closed chan struct{}
+}
+
+func newOutputWindow(p *trackedProcess) *outputWindow {
+	w := &outputWindow{
+		win:    fyne.CurrentApp().NewWindow("运行日志 - " + p.name),
+		text:   widget.NewMultiLineEntry(),
+		status: widget.NewLabel(""),
+		closed: make(chan struct{}),
+	}
+	w.text.TextStyle.Monospace = true
+	w.text.Wrapping = fyne.TextWrapBreak
+	w.stop = widget.NewButtonWithIcon("停止", theme.MediaStopIcon(), func() { w.proc.stop() })
+	restartBtn := widget.NewButtonWithIcon("重新启动", theme.MediaReplayIcon(), func() {
+		if activeTool != nil {
+			activeTool.restart(w.proc.id)
+		}
+	})
+	if activeTool == nil {
+		restartBtn.Disable()
+	}
+	bottom := container.NewHBox(w.status, layout.NewSpacer(), restartBtn, w.stop)
+	w.win.SetContent(container.NewBorder(nil, bottom, nil, nil, w.text))
+	w.win.Resize(fyne.NewSize(720, 480))
+	w.win.SetOnClosed(func() {
+		close(w.closed)
+		if w.proc.window == w {
+			w.proc.window = nil
+		}
+	})
+	w.attach(p)
+	w.win.Show()
+	go w.follow()
+	return w
+}
+
+// attach 让窗口显示新启动的进程，日志文件会在下一次读取时从头开始
+func (w *outputWindow) attach(p *trackedProcess) {
+	w.proc = p
+	w.offset = 0
+	w.text.SetText("")
+	w.update()
+}
+
+// update 刷新状态栏和停止按钮
+func (w *outputWindow) update() {
+	switch {
+	case w.proc.running:
+		w.status.SetText("运行中")
+		w.stop.Enable()
+	case w.proc.err != nil:
+		w.status.SetText("已结束: " + w.proc.err.Error())
+		w.stop.Disable()
+	default:
+		w.status.SetText("已结束，退出码 0")
+		w.stop.Disable()
+	}
+}
+
+// follow 定时读取日志文件新增的内容，直到窗口关闭
+func (w *outputWindow) follow() {
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var path string
+		var offset int64
+		fyne.DoAndWait(func() { path, offset = w.proc.logPath, w.offset })
+		data, newOffset, err := readFrom(path, offset)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("读取日志 %s 失败: %v", path, err)
+		}
+		if len(data) > 0 || newOffset != offset {
+			fyne.Do(func() {
+				if w.proc.logPath == path && w.offset == offset {
+					w.append(string(data), newOffset < offset)
+					w.offset = newOffset
+				}
+			})
+		}
+		select {
+		case <-w.closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readFrom 读取文件从 offset 开始的新内容，文件变短 (被重新创建) 时从头读取。
+// 一次最多读取 maxOutputLen 字节，跳过的部分从较早的内容中丢弃。
+func readFrom(path string, offset int64) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	size := info.Size()
+	if size < offset {
+		offset = 0
+	}
+	if size-offset > maxOutputLen {
+		offset = size - maxOutputLen
+	}
+	if size == offset {
+		return nil, offset, nil
+	}
+	data := make([]byte, size-offset)
+	n, err := f.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, offset, err
+	}
+	return data[:n], offset + int64(n), nil
+}
+
+// append 追加日志内容，reset 为 true 时先清空。只在 UI 线程中调用。
+func (w *outputWindow) append(s string, reset bool) {
+	if reset {
+		w.text.SetText("")
+	}
+	if len(w.text.Text)+len(s) <= maxOutputLen {
+		w.text.Append(s)
+		return
+	}
+	text := w.text.Text + s
+	cut := len(text) - maxOutputLen/2
+	if i := strings.IndexByte(text[cut:], '\n'); i >= 0 {
+		cut += i + 1
+	} else {
+		for cut < len(text) && !utf8.RuneStart(text[cut]) {
+			cut++
+		}
+	}
+	w.text.SetText(text[cut:])
+}
+
+// runScript 运行脚本并打开日志窗口显示输出
+func (t *localLauncherTool) runScript(conf ToolConfig) (<-chan error, error) {
+	cmd, err := scriptCommand(conf)
+	if err != nil {
+		return nil, err
+	}
+	_, done, err := startTracked(conf, cmd)
+	if err != nil {
+		return nil, err
+	}
+	showLog(conf.ID)
+	return done, nil
+}
@@ -0,0 +1,131 @@
+package locallauncher
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// pinyinForms 缓存名称的拼音全拼和首字母 (例如 "微信" -> "weixin", "wx")，非汉字按小写原样保留
+var pinyinForms = map[string][2]string{}
+
+func namePinyin(name string) (full, initials string) {
+	if forms, ok := pinyinForms[name]; ok {
+		return forms[0], forms[1]
+	}
+	args := pinyin.NewArgs()
+	var f, i strings.Builder
+	for _, c := range strings.ToLower(name) {
+		var py []string
+		if unicode.Is(unicode.Han, c) {
+			py = pinyin.SinglePinyin(c, args)
+		}
+		if len(py) == 0 {
+			f.WriteRune(c)
+			i.WriteRune(c)
+			continue
+		}
+		f.WriteString(py[0])
+		i.WriteByte(py[0][0])
+	}
+	pinyinForms[name] = [2]string{f.String(), i.String()}
+	return f.String(), i.String()
+}
+
+// fuzzyScore 判断 pattern 的字符是否按顺序出现在 text 中 (不区分大小写)。
+// 连续匹配、在开头或单词开头处匹配得分更高，完整包含 pattern 时额外加分。
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+	score, pi, prevMatch := 0, 0, -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == prevMatch+1 {
+			score += 4
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 6
+		}
+		prevMatch = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	if i := strings.Index(string(t), string(p)); i == 0 {
+		score += 20
+	} else if i > 0 {
+		score += 10
+	}
+	return score, true
+}
+
+// matchScore 把查询按空白拆分为多个词，每个词都要匹配名称、分组 (包括它们的拼音) 或路径之一。
+// 名称上的匹配权重最高，路径最低。
+func matchScore(query string, conf ToolConfig) (int, bool) {
+	full, initials := namePinyin(conf.Name)
+	groupFull, groupInitials := namePinyin(conf.Group)
+	fields := []struct {
+		text   string
+		weight int
+	}{{conf.Name, 3}, {initials, 3}, {full, 2}, {conf.Group, 2}, {groupInitials, 2}, {groupFull, 1}, {conf.Path, 1}}
+	total := 0
+	for _, word := range strings.Fields(query) {
+		best, matched := 0, false
+		for _, f := range fields {
+			if s, ok := fuzzyScore(word, f.text); ok {
+				matched = true
+				best = max(best, s*f.weight)
+			}
+		}
+		if !matched {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// frecency 综合启动次数和最近一次启动的时间，越常用、越近用过的条目得分越高
+func frecency(conf ToolConfig, now time.Time) float64 {
+	if conf.LaunchCount == 0 {
+		return 0
+	}
+	days := now.Sub(conf.LastLaunch).Hours() / 24
+	// 每过一周权重减半
+	return float64(conf.LaunchCount) * math.Pow(0.5, days/7)
+}
+
+// search 返回匹配查询的条目下标，按匹配程度和 frecency 排序。查询为空时按 frecency 返回所有条目。
+func (t *localLauncherTool) search(query string) []int {
+	now := time.Now()
+	type result struct {
+		index int
+		score float64
+	}
+	var results []result
+	for i, conf := range t.configs {
+		s, ok := matchScore(query, conf)
+		if !ok {
+			continue
+		}
+		// 常用程度只用来微调排名，不能让弱匹配压过强匹配
+		results = append(results, result{i, float64(s) + 10*math.Log1p(frecency(conf, now))})
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].score > results[b].score })
+	indices := make([]int, len(results))
+	for i, r := range results {
+		indices[i] = r.index
+	}
+	return indices
+}
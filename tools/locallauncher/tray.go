@@ -0,0 +1,192 @@
+package locallauncher
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 托盘菜单列出收藏的条目和按分组排列的所有条目；全局快捷键可以唤出工具箱或直接启动条目。
+// 两者都由主窗口安装，配置保存后自动更新。
+
+var (
+	trayApp fyne.App
+	trayWin fyne.Window
+	// hotkeyErr 是最近一次注册快捷键时的问题，显示在设置对话框中
+	hotkeyErr error
+)
+
+// launcherFor 返回当前打开的启动器，没有打开时读取一份配置
+func launcherFor(win fyne.Window) *localLauncherTool {
+	if activeTool != nil {
+		return activeTool
+	}
+	t := &localLauncherTool{win: win}
+	t.loadConfig()
+	return t
+}
+
+// InstallTray 安装托盘菜单、全局快捷键和 "关闭时最小化到托盘"。不支持托盘的平台只注册快捷键。
+func InstallTray(a fyne.App, win fyne.Window) {
+	trayApp, trayWin = a, win
+	win.SetCloseIntercept(func() {
+		if _, ok := a.(desktop.App); ok && launcherFor(win).settings.CloseToTray {
+			win.Hide()
+			return
+		}
+		a.Quit()
+	})
+	updateDesktopIntegration(launcherFor(win))
+}
+
+// updateDesktopIntegration 根据 t 的配置重建托盘菜单并重新注册快捷键，在配置保存后调用
+func updateDesktopIntegration(t *localLauncherTool) {
+	if trayWin == nil {
+		return
+	}
+	if desk, ok := trayApp.(desktop.App); ok {
+		desk.SetSystemTrayMenu(t.trayMenu())
+	}
+
+	var bindings []hotkeyBinding
+	if s := t.settings.SummonHotkey; s != "" {
+		if hk, err := parseHotkey(s); err == nil {
+			bindings = append(bindings, hotkeyBinding{hotkey: hk, label: "唤出工具箱", action: summon})
+		} else {
+			log.Printf("唤出快捷键无效: %v", err)
+		}
+	}
+	for _, c := range t.configs {
+		if c.Hotkey == "" {
+			continue
+		}
+		hk, err := parseHotkey(c.Hotkey)
+		if err != nil {
+			log.Printf("'%s' 的快捷键无效: %v", c.Name, err)
+			continue
+		}
+		bindings = append(bindings, hotkeyBinding{hotkey: hk, id: c.ID, label: c.Name, action: func() { launchByID(c.ID) }})
+	}
+	hotkeyErr = applyHotkeys(trayWin, bindings)
+}
+
+// summon 显示并激活主窗口，打开快速启动面板
+func summon() {
+	trayWin.Show()
+	trayWin.RequestFocus()
+	ShowPalette(trayWin)
+}
+
+// launchByID 从托盘或快捷键启动条目。启动失败时显示主窗口，让用户看到错误。
+func launchByID(id string) {
+	t := launcherFor(trayWin)
+	i := t.indexByID(id)
+	if i < 0 {
+		return
+	}
+	if !t.launch(i) {
+		trayWin.Show()
+	}
+}
+
+// trayMenu 构建托盘菜单：显示窗口、快速启动、收藏的条目、每个分组一个子菜单
+func (t *localLauncherTool) trayMenu() *fyne.Menu {
+	items := []*fyne.MenuItem{
+		fyne.NewMenuItem("显示雁陎工具集", func() {
+			trayWin.Show()
+			trayWin.RequestFocus()
+		}),
+		fyne.NewMenuItem("快速启动...", summon),
+	}
+	entryItem := func(c ToolConfig) *fyne.MenuItem {
+		label := c.Name
+		if c.Hotkey != "" {
+			label += "  (" + c.Hotkey + ")"
+		}
+		item := fyne.NewMenuItem(label, func() { launchByID(c.ID) })
+		item.Icon = t.getIconResource(c)
+		return item
+	}
+
+	var favorites []*fyne.MenuItem
+	for _, c := range t.configs {
+		if c.Favorite {
+			favorites = append(favorites, entryItem(c))
+		}
+	}
+	if len(favorites) > 0 {
+		items = append(append(items, fyne.NewMenuItemSeparator()), favorites...)
+	}
+
+	var groups []*fyne.MenuItem
+	for _, g := range t.groupOrder {
+		var children []*fyne.MenuItem
+		for _, c := range t.configs {
+			if c.Group == g {
+				children = append(children, entryItem(c))
+			}
+		}
+		if len(children) == 0 {
+			continue
+		}
+		group := fyne.NewMenuItem(g, nil)
+		group.ChildMenu = fyne.NewMenu(g, children...)
+		groups = append(groups, group)
+	}
+	if len(groups) > 0 {
+		items = append(append(items, fyne.NewMenuItemSeparator()), groups...)
+	}
+	return fyne.NewMenu("雁陎工具集", items...)
+}
+
+// showSettingsDialog 编辑托盘和全局快捷键的设置
+func (t *localLauncherTool) showSettingsDialog() {
+	closeToTray := widget.NewCheck("关闭窗口时最小化到托盘", nil)
+	closeToTray.SetChecked(t.settings.CloseToTray)
+	summonEntry := widget.NewEntry()
+	summonEntry.SetPlaceHolder("例如: Ctrl+Alt+Space")
+	summonEntry.SetText(t.settings.SummonHotkey)
+
+	status := "快捷键会注册为系统全局快捷键，在其他程序中也可以使用。"
+	switch {
+	case !globalHotkeysSupported:
+		status = "当前系统不支持全局快捷键，快捷键只在工具箱窗口中有效。"
+	case hotkeyErr != nil:
+		status = "以下快捷键无法注册为全局快捷键，只在工具箱窗口中有效:\n" + hotkeyErr.Error()
+	}
+	statusLabel := widget.NewLabel(status)
+	statusLabel.Wrapping = fyne.TextWrapWord
+	if _, ok := fyne.CurrentApp().(desktop.App); !ok {
+		closeToTray.Disable()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("", closeToTray),
+		widget.NewFormItem("唤出快捷键", summonEntry),
+		widget.NewFormItem("", statusLabel),
+	)
+	d := dialog.NewCustomConfirm("启动器设置", "保存", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		summonKey := ""
+		if s := summonEntry.Text; s != "" {
+			hk, err := parseHotkey(s)
+			if err == nil {
+				err = t.entryUsingHotkey(hk, -1)
+			}
+			if err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			summonKey = hk.String()
+		}
+		t.settings = LauncherSettings{CloseToTray: closeToTray.Checked, SummonHotkey: summonKey}
+		t.saveConfig()
+	}, t.win)
+	d.Resize(fyne.NewSize(480, 280))
+	d.Show()
+}
@@ -0,0 +1,210 @@
+package renamer_tool
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CaseRule 转换大小写。除了全部大写/小写，还支持按单词处理的标题格式、句子格式，
+// 以及 camelCase、snake_case、kebab-case 之间的转换。
+// 中文等没有大小写的文字保持不变，并视为单词的边界。
+type CaseRule struct {
+	ruleBase
+	CaseType   string
+	SmallWords []string // 标题格式中除首尾单词外保持小写的词，如 "a", "of"
+	Separators bool     // 把 '_'、'-' 和 '.' 也当作单词分隔符
+}
+
+var caseTypes = []string{"lower", "upper", "title", "sentence", "camel", "snake", "kebab"}
+var caseTypeLabels = []string{"全部小写", "全部大写", "标题格式 (Title Case)", "句子格式 (Sentence case)", "camelCase", "snake_case", "kebab-case"}
+
+// defaultSmallWords 是英文标题中通常不大写的冠词、连词和短介词
+var defaultSmallWords = []string{"a", "an", "and", "as", "at", "but", "by", "for", "in", "nor", "of", "on", "or", "the", "to", "vs", "via"}
+
+func (r *CaseRule) Apply(original string, item *FileItem, index int) string {
+	switch r.CaseType {
+	case "upper":
+		return strings.ToUpper(original)
+	case "lower":
+		return strings.ToLower(original)
+	case "title":
+		return r.titleCase(original)
+	case "sentence":
+		return r.sentenceCase(original)
+	case "camel":
+		return joinWords(r.splitWords(original), "", true)
+	case "snake":
+		return joinWords(r.splitWords(original), "_", false)
+	case "kebab":
+		return joinWords(r.splitWords(original), "-", false)
+	}
+	return original
+}
+
+func (r *CaseRule) Describe() string {
+	desc := r.CaseType
+	for i, t := range caseTypes {
+		if t == r.CaseType {
+			desc = caseTypeLabels[i]
+		}
+	}
+	if r.Separators && r.CaseType != "upper" && r.CaseType != "lower" {
+		desc += ", 分隔符 _ - ."
+	}
+	return fmt.Sprintf("大小写: %s", desc)
+}
+
+func (r *CaseRule) isSeparator(c rune) bool {
+	switch c {
+	case '_', '-', '.':
+		return r.Separators
+	}
+	return unicode.IsSpace(c)
+}
+
+// isCased 判断字符是否区分大小写。汉字、假名等不区分大小写的字母不算在单词内。
+func isCased(c rune) bool {
+	return unicode.IsUpper(c) || unicode.IsLower(c) || unicode.IsTitle(c)
+}
+
+func isApostrophe(c rune) bool { return c == '\'' || c == '’' }
+
+// wordSpan 是名称中一个单词的起止位置 (按 rune 计)
+type wordSpan struct{ start, end int }
+
+// titleWords 找出名称中需要处理大小写的单词：由区分大小写的字母和数字组成，
+// 夹在字母之间的撇号属于单词 ("don't")，因此撇号后的字母不会被大写。
+func titleWords(runes []rune) []wordSpan {
+	var words []wordSpan
+	inWord := func(i int) bool {
+		c := runes[i]
+		if isCased(c) || unicode.IsDigit(c) {
+			return true
+		}
+		return isApostrophe(c) && i > 0 && i+1 < len(runes) && isCased(runes[i-1]) && isCased(runes[i+1])
+	}
+	for i := 0; i < len(runes); {
+		if !inWord(i) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && inWord(i) {
+			i++
+		}
+		words = append(words, wordSpan{start, i})
+	}
+	return words
+}
+
+// capitalize 把第一个字母转为标题大写，其余字母转为小写
+func capitalize(word []rune) {
+	for i, c := range word {
+		if i == 0 {
+			word[i] = unicode.ToTitle(c)
+		} else {
+			word[i] = unicode.ToLower(c)
+		}
+	}
+}
+
+// titleCase 把每个单词首字母大写。单词之间只有被视为分隔符的字符 (空白，以及开启选项时的 _ - .)
+// 才算真正分开；不开启选项时 "my_file" 作为一个整体得到 "My_file"。
+func (r *CaseRule) titleCase(s string) string {
+	runes := []rune(strings.ToLower(s))
+	small := make(map[string]bool, len(r.SmallWords))
+	for _, w := range r.SmallWords {
+		small[strings.ToLower(strings.TrimSpace(w))] = true
+	}
+	words := titleWords(runes)
+	for n, w := range words {
+		// 前面紧挨着其他单词 (只隔着非分隔符，如 "my_file") 时不大写
+		if n > 0 && w.start > 0 && !r.isSeparator(runes[w.start-1]) && !startsWord(runes, w.start) {
+			continue
+		}
+		first, last := n == 0, n == len(words)-1
+		if !first && !last && small[string(runes[w.start:w.end])] {
+			continue
+		}
+		capitalize(runes[w.start:w.end])
+	}
+	return string(runes)
+}
+
+// startsWord 判断 i 前面的字符是否是括号、引号或非字母文字 (如汉字)，这些字符后面总是新单词
+func startsWord(runes []rune, i int) bool {
+	prev := runes[i-1]
+	if isApostrophe(prev) || prev == '_' || prev == '-' || prev == '.' {
+		return false
+	}
+	return !isCased(prev) && !unicode.IsDigit(prev)
+}
+
+// sentenceCase 只大写名称开头以及句号、问号、感叹号后的第一个字母
+func (r *CaseRule) sentenceCase(s string) string {
+	runes := []rune(strings.ToLower(s))
+	capNext := true
+	for i, c := range runes {
+		switch {
+		case isCased(c):
+			if capNext {
+				runes[i] = unicode.ToTitle(c)
+			}
+			capNext = false
+		case c == '.' || c == '!' || c == '?' || c == '。' || c == '！' || c == '？':
+			// "v1.2" 这样的点不算句子结束，后面必须跟空白
+			capNext = c != '.' || (i+1 < len(runes) && unicode.IsSpace(runes[i+1]))
+		case unicode.IsDigit(c):
+			capNext = false
+		}
+	}
+	return string(runes)
+}
+
+// splitWords 为 camelCase/snake_case/kebab-case 转换拆分单词：空白、'_'、'-' 总是分隔符，
+// '.' 在开启选项时才是；此外在 "fileName"、"HTTPServer" 这样的大小写变化处以及
+// 区分大小写的字母和汉字之间断开。
+func (r *CaseRule) splitWords(s string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	runes := []rune(s)
+	for i, c := range runes {
+		if unicode.IsSpace(c) || c == '_' || c == '-' || (c == '.' && r.Separators) {
+			flush()
+			continue
+		}
+		if len(cur) > 0 {
+			prev := cur[len(cur)-1]
+			switch {
+			case unicode.IsUpper(c) && unicode.IsLower(prev):
+				flush()
+			case unicode.IsUpper(c) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				flush() // "HTTPServer" -> "HTTP", "Server"
+			case isCased(c) != isCased(prev) && (unicode.IsLetter(c) && unicode.IsLetter(prev)):
+				flush() // "中文File" -> "中文", "File"
+			}
+		}
+		cur = append(cur, c)
+	}
+	flush()
+	return words
+}
+
+// joinWords 连接单词，camel 为 true 时除第一个单词外首字母大写，否则全部小写
+func joinWords(words []string, sep string, camel bool) string {
+	for i, w := range words {
+		runes := []rune(strings.ToLower(w))
+		if camel && i > 0 {
+			capitalize(runes)
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, sep)
+}
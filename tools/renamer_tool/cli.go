@@ -0,0 +1,166 @@
+package renamer_tool
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// 命令行模式的退出码
+const (
+	exitOK       = 0
+	exitError    = 1 // 参数、预设错误或重命名失败
+	exitConflict = 2 // 存在命名冲突，没有执行任何重命名
+)
+
+// RunCLI 执行 "yanshu-toolkit rename" 子命令：用 data/renamer 中保存的预设批量重命名，
+// 规则的应用方式与界面中的预览完全相同。返回值用作进程退出码。
+//
+//	yanshu-toolkit rename --preset 测试 --recursive ./photos --dry-run
+func RunCLI(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("rename", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	presetName := flags.String("preset", "", "要使用的预设名称 (data/renamer 下的文件名，不含 .json)")
+	recursive := flags.Bool("recursive", false, "递归处理文件夹中的所有子文件夹")
+	dryRun := flags.Bool("dry-run", false, "只显示重命名结果，不实际修改文件")
+	include := flags.String("include", "files", "参与重命名的条目: files, folders 或 all")
+	sortKey := flags.String("sort", "", "编号顺序: name, natural, size, mtime, path (默认按扫描顺序)")
+	copyMode := flags.Bool("copy", false, "复制到新名称，保留原文件")
+	multiExt := flags.String("multi-ext", strings.Join(defaultMultiExts, ","), "视为一个整体的多段扩展名，逗号分隔")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "用法: yanshu-toolkit rename --preset <名称> [选项] <文件或文件夹>...")
+		flags.PrintDefaults()
+	}
+
+	// 允许选项和路径交替出现，例如 "--recursive ./photos --dry-run"
+	var paths []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			return exitError
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		paths = append(paths, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if *presetName == "" || len(paths) == 0 {
+		flags.Usage()
+		return exitError
+	}
+	itemMode := map[string]int{"files": modeFilesOnly, "folders": modeFoldersOnly, "all": modeFilesAndFolders}
+	mode, ok := itemMode[*include]
+	if !ok {
+		fmt.Fprintf(stderr, "无效的 --include: %s\n", *include)
+		return exitError
+	}
+	switch *sortKey {
+	case sortNone, sortName, sortNatural, sortSize, sortModTime, sortPath:
+	default:
+		fmt.Fprintf(stderr, "无效的 --sort: %s\n", *sortKey)
+		return exitError
+	}
+
+	preset, _, err := readPresetFile(filepath.Join(presetsDir, *presetName+".json"))
+	if err != nil {
+		fmt.Fprintf(stderr, "无法加载预设 '%s': %v\n", *presetName, err)
+		return exitError
+	}
+	rules, err := preset.BuildRules()
+	if err != nil {
+		fmt.Fprintf(stderr, "预设 '%s' 无效:\n%v\n", *presetName, err)
+		return exitError
+	}
+
+	items, err := collectCLIItems(paths, *recursive, mode)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	sortItems(items, *sortKey, false)
+	computeNewNames(rules, items, parseMultiExts(*multiExt))
+
+	var pending []*FileItem
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "原路径\t->\t新名称")
+	for _, item := range items {
+		if item.NewName == item.OriginalName {
+			continue
+		}
+		pending = append(pending, item)
+		fmt.Fprintf(tw, "%s\t->\t%s\n", item.OriginalPath, item.NewName)
+	}
+	tw.Flush()
+	fmt.Fprintf(stdout, "共 %d 个条目，其中 %d 个需要重命名。\n", len(items), len(pending))
+
+	if conflicts := findConflicts(pending, *copyMode); len(conflicts) > 0 {
+		fmt.Fprintf(stderr, "发现 %d 个冲突，未执行重命名:\n", len(conflicts))
+		for _, c := range conflicts {
+			fmt.Fprintln(stderr, "  "+c)
+		}
+		return exitConflict
+	}
+	if *dryRun {
+		fmt.Fprintln(stdout, "试运行 (--dry-run)，未修改任何文件。")
+		return exitOK
+	}
+
+	result := renameItems(context.Background(), pending, items, *copyMode, nil)
+	result.apply()
+	renamed, failed := result.renamed, result.failed
+	verb := "重命名"
+	if *copyMode {
+		verb = "复制"
+	}
+	fmt.Fprintf(stdout, "%s完成。成功: %d, 失败: %d\n", verb, renamed, failed)
+	if failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// collectCLIItems 收集命令行给出的路径。文件直接加入；文件夹默认只处理其直接子项，
+// --recursive 时处理全部后代 (不含文件夹本身)。
+func collectCLIItems(paths []string, recursive bool, mode int) ([]*FileItem, error) {
+	var items []*FileItem
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("无法访问 %s: %v", root, err)
+		}
+		if !info.IsDir() {
+			items = append(items, newFileItem(root))
+			continue
+		}
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == root {
+				return nil
+			}
+			if itemModeAccepts(mode, d.IsDir()) {
+				items = append(items, newFileItem(p))
+			}
+			if d.IsDir() && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("扫描 %s 失败: %v", root, err)
+		}
+	}
+	return items, nil
+}
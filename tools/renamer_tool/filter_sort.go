@@ -0,0 +1,119 @@
+package renamer_tool
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 预览列表的排序字段，空字符串表示保持添加顺序
+const (
+	sortNone    = ""
+	sortName    = "name"
+	sortNatural = "natural"
+	sortSize    = "size"
+	sortModTime = "mtime"
+	sortPath    = "path"
+)
+
+// naturalLess 按 "自然顺序" 比较两个字符串：数字部分按数值比较，因此 "2" 排在 "10" 之前。
+// 字母部分不区分大小写。
+func naturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		ca, restA := nextChunk(a)
+		cb, restB := nextChunk(b)
+		if ca != cb {
+			da, db := isDigit(ca[0]), isDigit(cb[0])
+			switch {
+			case da && db:
+				na, nb := strings.TrimLeft(ca, "0"), strings.TrimLeft(cb, "0")
+				if len(na) != len(nb) {
+					return len(na) < len(nb)
+				}
+				if na != nb {
+					return na < nb
+				}
+				// 数值相同时，前导零少的排前面
+				return len(ca) < len(cb)
+			default:
+				return ca < cb
+			}
+		}
+		a, b = restA, restB
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// nextChunk 取出开头连续的数字或非数字部分
+func nextChunk(s string) (string, string) {
+	digit := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// sortItems 按指定字段对文件列表做稳定排序
+func sortItems(items []*FileItem, key string, desc bool) {
+	if key == sortNone {
+		if desc {
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+		}
+		return
+	}
+	less := func(a, b *FileItem) bool {
+		switch key {
+		case sortName:
+			return strings.ToLower(a.OriginalName) < strings.ToLower(b.OriginalName)
+		case sortNatural:
+			return naturalLess(a.OriginalName, b.OriginalName)
+		case sortSize:
+			return a.Size < b.Size
+		case sortModTime:
+			return a.ModTime.Before(b.ModTime)
+		case sortPath:
+			return naturalLess(a.OriginalPath, b.OriginalPath)
+		}
+		return false
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+// newNameMatcher 根据过滤框的内容创建匹配函数。
+// 通配符模式下，不含通配符的文本按 "包含" 处理；正则模式下表达式无效时返回错误。
+func newNameMatcher(pattern string, useRegex bool) (func(string) bool, error) {
+	if pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+	if useRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的正则表达式: %v", err)
+		}
+		return re.MatchString, nil
+	}
+	pattern = strings.ToLower(pattern)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return func(name string) bool { return strings.Contains(strings.ToLower(name), pattern) }, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("无效的通配符: %v", err)
+	}
+	return func(name string) bool {
+		ok, _ := filepath.Match(pattern, strings.ToLower(name))
+		return ok
+	}, nil
+}
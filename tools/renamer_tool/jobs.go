@@ -0,0 +1,386 @@
+package renamer_tool
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// 扫描、预览计算和重命名都在后台 goroutine 中执行，结果通过 fyne.Do 交回 UI 线程。
+// 后台代码只读取 FileItem，所有写入 (包括 renameResult.apply) 都发生在 UI 线程中。
+
+const (
+	previewDebounce  = 150 * time.Millisecond // 规则或过滤条件变化后等待多久再重新计算预览
+	progressInterval = 100 * time.Millisecond // 后台任务向界面报告进度的最短间隔
+)
+
+// throttle 限制进度回调的频率，最后一次 (done == total) 总会被报告
+func throttle(fn func(done, total int)) func(done, total int) {
+	var last time.Time
+	return func(done, total int) {
+		if done < total && time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		fn(done, total)
+	}
+}
+
+// --- 扫描和重命名任务 (同一时间只允许一个) ---
+
+// beginJob 开始一个扫描或重命名任务，已有任务在进行时提示用户并返回 false
+func (t *renamerTool) beginJob(text string, determinate bool) (context.Context, bool) {
+	if t.jobCancel != nil {
+		dialog.ShowInformation("提示", "请等待当前任务完成，或先点击取消。", t.win)
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.jobCancel = cancel
+	for _, b := range t.jobButtons {
+		b.Disable()
+	}
+	if determinate {
+		t.progressBar.SetValue(0)
+		t.progressBar.Show()
+	} else {
+		t.scanProgress.Show()
+		t.scanProgress.Start()
+	}
+	t.jobText = text
+	t.refreshStatus()
+	return ctx, true
+}
+
+func (t *renamerTool) setJobProgress(text string, done, total int) {
+	t.jobText = text
+	if total > 0 {
+		t.progressBar.SetValue(float64(done) / float64(total))
+	}
+	t.refreshStatus()
+}
+
+func (t *renamerTool) endJob() {
+	if t.jobCancel != nil {
+		t.jobCancel()
+		t.jobCancel = nil
+	}
+	for _, b := range t.jobButtons {
+		b.Enable()
+	}
+	t.progressBar.Hide()
+	t.scanProgress.Stop()
+	t.scanProgress.Hide()
+	t.jobText = ""
+	t.refreshStatus()
+}
+
+// cancelJobs 取消正在进行的任务和预览计算
+func (t *renamerTool) cancelJobs() {
+	if t.jobCancel != nil {
+		t.jobCancel()
+	}
+	t.renameQueued = false
+	t.cancelPreview()
+}
+
+// refreshStatus 更新底部的状态栏
+func (t *renamerTool) refreshStatus() {
+	if t.statusLabel == nil {
+		return
+	}
+	text := fmt.Sprintf("共 %d 项，显示 %d 项，将重命名 %d 项", len(t.fileItems), len(t.visibleItems), t.changedCount)
+	if t.previewText != "" {
+		text = t.previewText
+	}
+	if t.jobText != "" {
+		text = t.jobText
+	}
+	t.statusLabel.SetText(text)
+	if n := len(t.mappingReport.Unmatched) + len(t.mappingReport.Unused); n > 0 {
+		t.mappingBtn.SetText(fmt.Sprintf("映射问题 (%d)", n))
+		t.mappingBtn.Show()
+	} else {
+		t.mappingBtn.Hide()
+	}
+	if t.jobCancel != nil || t.previewCancel != nil {
+		t.cancelBtn.Show()
+	} else {
+		t.cancelBtn.Hide()
+	}
+}
+
+// startScan 在后台扫描给定的文件和文件夹 (文件夹递归收集所有后代，不含其本身)。
+// 找到的条目分批加入列表，每批之后预览会在防抖后刷新，因此扫描大目录时可以边扫描边查看。
+func (t *renamerTool) startScan(roots []string) {
+	ctx, ok := t.beginJob("正在扫描...", false)
+	if !ok {
+		return
+	}
+	go func() {
+		var batch []*FileItem
+		found := 0
+		last := time.Now()
+		flush := func() {
+			items, n := batch, found
+			batch = nil
+			fyne.Do(func() {
+				t.fileItems = append(t.fileItems, items...)
+				t.jobText = fmt.Sprintf("正在扫描... 已找到 %d 项", n)
+				t.updatePreviews()
+			})
+		}
+		add := func(path string) {
+			batch = append(batch, newFileItem(path))
+			found++
+			if time.Since(last) >= progressInterval*2 {
+				flush()
+				last = time.Now()
+			}
+		}
+
+		for _, root := range roots {
+			info, err := os.Stat(root)
+			if err != nil {
+				log.Printf("无法访问 %s: %v", root, err)
+				continue
+			}
+			if !info.IsDir() {
+				add(root)
+				continue
+			}
+			filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+				if ctx.Err() != nil {
+					return filepath.SkipAll
+				}
+				if err == nil && p != root {
+					add(p)
+				}
+				return nil
+			})
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		cancelled := ctx.Err() != nil
+		flush()
+		fyne.Do(func() {
+			t.endJob()
+			if cancelled {
+				dialog.ShowInformation("扫描已取消", fmt.Sprintf("已添加扫描到的 %d 项。", found), t.win)
+			}
+		})
+	}()
+}
+
+// startRename 在后台重命名 pending 中的条目，结束后在 UI 线程中写回结果
+func (t *renamerTool) startRename(pending []*FileItem) {
+	ctx, ok := t.beginJob("正在重命名...", true)
+	if !ok {
+		return
+	}
+	// 重命名期间预览计算会读取到正在变化的文件系统，因此先停止并丢弃进行中的预览
+	t.cancelPreview()
+	t.renaming = true
+	// 上一次重命名的结果保留到现在，开始新的重命名时才清除
+	for _, item := range t.fileItems {
+		item.Status = ""
+		item.ErrorMessage = ""
+		item.PreviousPath = ""
+	}
+	all := append([]*FileItem(nil), t.fileItems...)
+	copyMode := t.copyMode
+	go func() {
+		progress := throttle(func(done, total int) {
+			fyne.Do(func() { t.setJobProgress(fmt.Sprintf("正在重命名... %d/%d", done, total), done, total) })
+		})
+		result := renameItems(ctx, pending, all, copyMode, progress)
+		fyne.Do(func() {
+			result.apply()
+			t.renaming = false
+			t.endJob()
+			t.previewList.Refresh()
+			msg := fmt.Sprintf("重命名完成。\n成功: %d\n失败: %d", result.renamed, result.failed)
+			if copyMode {
+				msg = fmt.Sprintf("复制完成。\n成功: %d\n失败: %d", result.renamed, result.failed)
+			}
+			if result.skipped > 0 {
+				msg += fmt.Sprintf("\n已取消，未处理: %d", result.skipped)
+			}
+			dialog.ShowInformation("完成", msg, t.win)
+		})
+	}()
+}
+
+// --- 预览 ---
+
+// updatePreviews 在短暂的防抖之后重新过滤、排序文件列表并计算新名称。
+// 序号按当前显示顺序只分配给勾选的文件，被过滤掉或取消勾选的文件保持原名。
+func (t *renamerTool) updatePreviews() {
+	if t.previewTimer != nil {
+		t.previewTimer.Stop()
+	}
+	t.previewPending = true
+	t.previewTimer = time.AfterFunc(previewDebounce, func() { fyne.Do(t.startPreview) })
+}
+
+// cancelPreview 停止等待中和进行中的预览计算，进行中的结果会被丢弃
+func (t *renamerTool) cancelPreview() {
+	if t.previewTimer != nil {
+		t.previewTimer.Stop()
+	}
+	if t.previewCancel != nil {
+		t.previewCancel()
+		t.previewCancel = nil
+	}
+	t.previewGen++
+	t.previewText = ""
+	t.refreshStatus()
+}
+
+// previewSnapshot 是计算预览所需状态的副本，后台计算期间界面上的修改不会影响它
+type previewSnapshot struct {
+	items       []*FileItem
+	excluded    map[*FileItem]bool
+	rules       []Rule
+	filterText  string
+	filterRegex bool
+	sortKey     string
+	sortDesc    bool
+	itemMode    int
+	multiExts   []string
+}
+
+func (t *renamerTool) startPreview() {
+	t.previewPending = false
+	if t.renaming {
+		return // 重命名结束后不自动刷新，保留成功/失败状态
+	}
+	t.cancelPreview()
+	snap := previewSnapshot{
+		items:       append([]*FileItem(nil), t.fileItems...),
+		excluded:    make(map[*FileItem]bool),
+		filterText:  t.filterText,
+		filterRegex: t.filterRegex,
+		sortKey:     t.sortKey,
+		sortDesc:    t.sortDesc,
+		itemMode:    t.itemMode,
+		multiExts:   t.multiExts,
+	}
+	for _, item := range snap.items {
+		if item.Excluded {
+			snap.excluded[item] = true
+		}
+	}
+	// 规则的 Prepare 会修改规则本身，所以后台使用副本
+	for _, rule := range t.rules {
+		clone, err := cloneRule(rule)
+		if err != nil {
+			log.Printf("无法复制规则 %s: %v", rule.Describe(), err)
+			t.renameQueued = false
+			t.refreshStatus()
+			dialog.ShowError(fmt.Errorf("无法计算预览，规则 '%s' 有误: %v", rule.Describe(), err), t.win)
+			return
+		}
+		snap.rules = append(snap.rules, clone)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.previewCancel = cancel
+	gen := t.previewGen
+	go func() {
+		progress := throttle(func(done, total int) {
+			if done == total {
+				return
+			}
+			fyne.Do(func() {
+				if gen == t.previewGen {
+					t.previewText = fmt.Sprintf("正在计算预览... %d/%d", done, total)
+					t.refreshStatus()
+				}
+			})
+		})
+		visible, names, err := snap.compute(ctx, progress)
+		fyne.Do(func() {
+			if gen != t.previewGen {
+				return // 已被更新的预览或取消操作取代
+			}
+			cancel()
+			t.previewCancel = nil
+			t.previewText = ""
+			if err != nil {
+				t.renameQueued = false
+				t.refreshStatus()
+				return
+			}
+			for _, item := range snap.items {
+				item.NewName = item.OriginalName
+			}
+			t.changedCount = 0
+			for i, item := range visible {
+				item.NewName = names[i]
+				if names[i] != item.OriginalName {
+					t.changedCount++
+				}
+			}
+			t.visibleItems = visible
+			t.mappingReport = mappingReport{}
+			for _, rule := range snap.rules {
+				if m, ok := rule.(*MappingRule); ok && !m.Disabled {
+					t.mappingReport.Unmatched = append(t.mappingReport.Unmatched, m.report.Unmatched...)
+					t.mappingReport.Unused = append(t.mappingReport.Unused, m.report.Unused...)
+				}
+			}
+			t.previewList.Refresh()
+			t.refreshStatus()
+			if t.renameQueued {
+				t.renameQueued = false
+				t.executeRename()
+			}
+		})
+	}()
+}
+
+// compute 过滤、排序并计算新名称，names 与 visible 一一对应
+func (s *previewSnapshot) compute(ctx context.Context, progress func(done, total int)) (visible []*FileItem, names []string, err error) {
+	match, err := newNameMatcher(s.filterText, s.filterRegex)
+	if err != nil {
+		match = func(string) bool { return false }
+	}
+	for _, item := range s.items {
+		if itemModeAccepts(s.itemMode, item.IsDir) && match(item.OriginalName) {
+			visible = append(visible, item)
+		}
+	}
+	sortItems(visible, s.sortKey, s.sortDesc)
+
+	var included []*FileItem
+	for _, item := range visible {
+		if !s.excluded[item] {
+			included = append(included, item)
+		}
+	}
+	newNamesIncluded, err := newNames(ctx, s.rules, included, s.multiExts, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	byItem := make(map[*FileItem]string, len(included))
+	for i, item := range included {
+		byItem[item] = newNamesIncluded[i]
+	}
+	names = make([]string, len(visible))
+	for i, item := range visible {
+		if name, ok := byItem[item]; ok {
+			names[i] = name
+		} else {
+			names[i] = item.OriginalName
+		}
+	}
+	return visible, names, nil
+}
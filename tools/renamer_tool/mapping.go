@@ -0,0 +1,249 @@
+package renamer_tool
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MappingRule 用一份名称列表重命名：按顺序时第 n 个文件得到第 n 个名称；
+// 按原名称匹配时查找与文件名 (或去掉扩展名后的名称) 相同的键。
+type MappingRule struct {
+	ruleBase
+	ByKey bool
+	Pairs []mappingPair
+
+	// Prepare 计算出的对应关系和问题报告
+	matched map[*FileItem]string
+	report  mappingReport
+}
+
+type mappingPair struct {
+	Key, Value string // 按顺序时 Key 为空
+}
+
+// mappingReport 列出没有对应名称的文件和没有用到的映射
+type mappingReport struct {
+	Unmatched []string
+	Unused    []string
+}
+
+func (r *MappingRule) Prepare(items []*FileItem) {
+	r.matched = make(map[*FileItem]string, len(items))
+	r.report = mappingReport{}
+	if !r.ByKey {
+		for i, item := range items {
+			if i < len(r.Pairs) {
+				r.matched[item] = r.Pairs[i].Value
+			} else {
+				r.report.Unmatched = append(r.report.Unmatched, item.OriginalPath)
+			}
+		}
+		for _, p := range r.Pairs[min(len(items), len(r.Pairs)):] {
+			r.report.Unused = append(r.report.Unused, p.Value)
+		}
+		return
+	}
+
+	values := make(map[string]string, len(r.Pairs))
+	for _, p := range r.Pairs {
+		values[p.Key] = p.Value
+	}
+	used := make(map[string]bool)
+	for _, item := range items {
+		key := item.OriginalName
+		value, ok := values[key]
+		if !ok && !item.IsDir {
+			key = strings.TrimSuffix(item.OriginalName, filepath.Ext(item.OriginalName))
+			value, ok = values[key]
+		}
+		if !ok {
+			r.report.Unmatched = append(r.report.Unmatched, item.OriginalPath)
+			continue
+		}
+		r.matched[item] = value
+		used[key] = true
+	}
+	for _, p := range r.Pairs {
+		if !used[p.Key] {
+			r.report.Unused = append(r.report.Unused, fmt.Sprintf("%s -> %s", p.Key, p.Value))
+		}
+	}
+}
+
+func (r *MappingRule) Apply(original string, item *FileItem, index int) string {
+	if value, ok := r.matched[item]; ok {
+		return value
+	}
+	return original
+}
+
+func (r *MappingRule) Describe() string {
+	mode := "按顺序"
+	if r.ByKey {
+		mode = "按原名称匹配"
+	}
+	return fmt.Sprintf("名称映射: %s, %d 条", mode, len(r.Pairs))
+}
+
+// parseMappingText 解析映射文本框的内容。按顺序时每行是一个新名称；
+// 按原名称匹配时每行是 "原名称<Tab>新名称"，没有 Tab 时用第一个逗号分隔。空行被忽略。
+func parseMappingText(text string, byKey bool) ([]mappingPair, error) {
+	var pairs []mappingPair
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !byKey {
+			pairs = append(pairs, mappingPair{Value: strings.TrimSpace(line)})
+			continue
+		}
+		sep := "\t"
+		if !strings.Contains(line, sep) {
+			sep = ","
+		}
+		key, value, ok := strings.Cut(line, sep)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行缺少分隔符 (Tab 或逗号): %s", i+1, line)
+		}
+		pairs = append(pairs, mappingPair{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return pairs, nil
+}
+
+// formatMappingText 是 parseMappingText 的逆操作，用于在编辑对话框中显示已有的映射
+func formatMappingText(pairs []mappingPair, byKey bool) string {
+	var lines []string
+	for _, p := range pairs {
+		if byKey {
+			lines = append(lines, p.Key+"\t"+p.Value)
+		} else {
+			lines = append(lines, p.Value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readTable 读取 CSV 或 TSV 内容。扩展名为 .tsv 或第一行包含 Tab 时按 TSV 解析。
+func readTable(data []byte, ext string) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel 导出的 UTF-8 BOM
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	if strings.EqualFold(ext, ".tsv") || bytes.Contains(firstLine, []byte("\t")) {
+		reader.Comma = '\t'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("无法解析表格: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("表格为空")
+	}
+	return rows, nil
+}
+
+// showImportMappingDialog 让用户选择 CSV/TSV 文件以及作为原名称和新名称的列，
+// 然后以映射文本的格式交给 onImported。
+func (t *renamerTool) showImportMappingDialog(byKey bool, onImported func(text string)) {
+	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, t.win)
+			return
+		}
+		rows, err := readTable(data, reader.URI().Extension())
+		if err != nil {
+			dialog.ShowError(err, t.win)
+			return
+		}
+		t.showMappingColumnsDialog(rows, byKey, onImported)
+	}, t.win)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".tsv", ".txt"}))
+	fd.Show()
+}
+
+func (t *renamerTool) showMappingColumnsDialog(rows [][]string, byKey bool, onImported func(text string)) {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var options []string
+	for i := 0; i < columns; i++ {
+		sample := ""
+		if i < len(rows[0]) {
+			sample = rows[0][i]
+		}
+		options = append(options, fmt.Sprintf("第 %d 列 (%s)", i+1, sample))
+	}
+	keySelect := widget.NewSelect(options, nil)
+	valueSelect := widget.NewSelect(options, nil)
+	keySelect.SetSelectedIndex(0)
+	valueSelect.SetSelectedIndex(min(1, columns-1))
+	headerCheck := widget.NewCheck("第一行是表头", nil)
+
+	form := widget.NewForm()
+	if byKey {
+		form.Append("原名称所在列:", keySelect)
+	}
+	form.Append("新名称所在列:", valueSelect)
+	form.Append("", headerCheck)
+	dialog.ShowCustomConfirm(fmt.Sprintf("导入映射 (%d 行)", len(rows)), "导入", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		keyCol, valueCol := keySelect.SelectedIndex(), valueSelect.SelectedIndex()
+		cell := func(row []string, col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		var pairs []mappingPair
+		for i, row := range rows {
+			if i == 0 && headerCheck.Checked {
+				continue
+			}
+			p := mappingPair{Value: cell(row, valueCol)}
+			if byKey {
+				p.Key = cell(row, keyCol)
+			}
+			if p.Key != "" || p.Value != "" {
+				pairs = append(pairs, p)
+			}
+		}
+		onImported(formatMappingText(pairs, byKey))
+	}, t.win)
+}
+
+// showMappingReport 显示映射规则在当前预览中未匹配的文件和未使用的映射
+func (t *renamerTool) showMappingReport() {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "没有对应新名称的文件 (%d):\n", len(t.mappingReport.Unmatched))
+	for _, s := range t.mappingReport.Unmatched {
+		sb.WriteString("  " + s + "\n")
+	}
+	fmt.Fprintf(&sb, "\n没有用到的映射 (%d):\n", len(t.mappingReport.Unused))
+	for _, s := range t.mappingReport.Unused {
+		sb.WriteString("  " + s + "\n")
+	}
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(sb.String())
+	d := dialog.NewCustom("名称映射报告", "关闭", container.NewScroll(entry), t.win)
+	d.Resize(fyne.NewSize(600, 420))
+	d.Show()
+}
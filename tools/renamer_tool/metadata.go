@@ -0,0 +1,606 @@
+package renamer_tool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	// 注册 image.DecodeConfig 需要的解码器
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// fileMetadata 缓存一个文件的元数据，只在模板规则第一次用到时才读取。
+type fileMetadata struct {
+	Width, Height int
+	Exif          map[string]string
+	Tags          map[string]string // 音频标签 (ID3 / FLAC / MP4)，键统一为小写
+}
+
+// metaCache 挂在 FileItem 上，保证每个文件的元数据只解析一次。
+type metaCache struct {
+	once sync.Once
+	meta *fileMetadata
+}
+
+// Metadata 返回文件的元数据，解析失败的部分保持为空。
+func (f *FileItem) Metadata() *fileMetadata {
+	f.meta.once.Do(func() {
+		f.meta.meta = loadMetadata(f.OriginalPath)
+	})
+	return f.meta.meta
+}
+
+// 单个文件最多读取这么多字节用于解析元数据，避免把整部电影读进内存。
+const maxMetaRead = 16 << 20
+
+func loadMetadata(path string) *fileMetadata {
+	m := &fileMetadata{Exif: map[string]string{}, Tags: map[string]string{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return m
+	}
+	defer file.Close()
+
+	if cfg, _, err := image.DecodeConfig(file); err == nil {
+		m.Width, m.Height = cfg.Width, cfg.Height
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return m
+	}
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+	file.Seek(0, io.SeekStart)
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8}):
+		data, _ := io.ReadAll(io.LimitReader(file, maxMetaRead))
+		if tiff := findJPEGExif(data); tiff != nil {
+			parseExif(tiff, m.Exif)
+		}
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		data, _ := io.ReadAll(io.LimitReader(file, maxMetaRead))
+		parseExif(data, m.Exif)
+	case bytes.HasPrefix(head, []byte("ID3")):
+		data, _ := io.ReadAll(io.LimitReader(file, maxMetaRead))
+		parseID3v2(data, m.Tags)
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		data, _ := io.ReadAll(io.LimitReader(file, maxMetaRead))
+		parseFLAC(data, m.Tags)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		parseMP4(file, m.Tags)
+	}
+
+	// 没有 ID3v2 的 MP3 可能只有文件末尾的 ID3v1
+	if len(m.Tags) == 0 && strings.EqualFold(filepath.Ext(path), ".mp3") {
+		parseID3v1(file, m.Tags)
+	}
+	return m
+}
+
+// --- EXIF ---
+
+// exifTagNames 只收录常用于命名的标签，未收录的标签可用 0x 十六进制编号访问。
+var exifTagNames = map[uint16]string{
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x920A: "FocalLength",
+	0xA002: "PixelXDimension",
+	0xA003: "PixelYDimension",
+	0xA434: "LensModel",
+}
+
+const (
+	exifIFDPointer = 0x8769
+	exifDateLayout = "2006:01:02 15:04:05"
+)
+
+func findJPEGExif(data []byte) []byte {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xD9 || marker == 0xDA { // EOI / SOS 之后不再有元数据段
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if size < 2 || pos+2+size > len(data) {
+			return nil
+		}
+		seg := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:]
+		}
+		pos += 2 + size
+	}
+	return nil
+}
+
+func parseExif(tiff []byte, out map[string]string) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd0 := order.Uint32(tiff[4:])
+	exifIFD := readIFD(tiff, order, ifd0, out)
+	if exifIFD > 0 {
+		readIFD(tiff, order, exifIFD, out)
+	}
+}
+
+// readIFD 读取一个 IFD 中的条目写入 out，返回其中 Exif 子 IFD 的偏移 (没有则为 0)。
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, out map[string]string) uint32 {
+	if int(offset)+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	var subIFD uint32
+	for i := 0; i < count; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry:])
+		typ := order.Uint16(tiff[entry+2:])
+		n := order.Uint32(tiff[entry+4:])
+		if tag == exifIFDPointer {
+			subIFD = order.Uint32(tiff[entry+8:])
+			continue
+		}
+		value, ok := exifValue(tiff, order, typ, n, tiff[entry+8:entry+12])
+		if !ok {
+			continue
+		}
+		name, known := exifTagNames[tag]
+		if !known {
+			name = fmt.Sprintf("0x%04X", tag)
+		}
+		out[name] = value
+	}
+	return subIFD
+}
+
+func exifValue(tiff []byte, order binary.ByteOrder, typ uint16, count uint32, inline []byte) (string, bool) {
+	sizes := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+	unit, ok := sizes[typ]
+	if !ok || count == 0 || count > 1<<16 {
+		return "", false
+	}
+	total := unit * int(count)
+	data := inline
+	if total > 4 {
+		off := int(order.Uint32(inline))
+		if off+total > len(tiff) {
+			return "", false
+		}
+		data = tiff[off : off+total]
+	}
+	data = data[:total]
+
+	switch typ {
+	case 2: // ASCII
+		return strings.TrimSpace(strings.TrimRight(string(data), "\x00")), true
+	case 3: // SHORT
+		return strconv.Itoa(int(order.Uint16(data))), true
+	case 4: // LONG
+		return strconv.FormatUint(uint64(order.Uint32(data)), 10), true
+	case 9: // SLONG
+		return strconv.Itoa(int(int32(order.Uint32(data)))), true
+	case 5, 10: // RATIONAL / SRATIONAL
+		num, den := int64(order.Uint32(data)), int64(order.Uint32(data[4:]))
+		if typ == 10 {
+			num, den = int64(int32(num)), int64(int32(den))
+		}
+		return formatRational(num, den), true
+	case 1, 7: // BYTE / UNDEFINED
+		if count == 1 {
+			return strconv.Itoa(int(data[0])), true
+		}
+		return strings.TrimRight(string(data), "\x00"), true
+	}
+	return "", false
+}
+
+func formatRational(num, den int64) string {
+	if den == 0 {
+		return "0"
+	}
+	if num == 1 && den > 1 { // 曝光时间这类 1/250 保留分数形式
+		return fmt.Sprintf("1/%d", den)
+	}
+	return strconv.FormatFloat(float64(num)/float64(den), 'f', -1, 64)
+}
+
+// --- ID3 ---
+
+var id3FrameNames = map[string]string{
+	"TIT2": "title", "TT2": "title",
+	"TPE1": "artist", "TP1": "artist",
+	"TPE2": "albumartist", "TP2": "albumartist",
+	"TALB": "album", "TAL": "album",
+	"TYER": "year", "TYE": "year", "TDRC": "year",
+	"TRCK": "track", "TRK": "track",
+	"TPOS": "disc", "TPA": "disc",
+	"TCON": "genre", "TCO": "genre",
+	"TCOM": "composer", "TCM": "composer",
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+func parseID3v2(data []byte, out map[string]string) {
+	if len(data) < 10 {
+		return
+	}
+	version := data[3]
+	flags := data[5]
+	end := 10 + syncsafe(data[6:10])
+	if end > len(data) {
+		end = len(data)
+	}
+	pos := 10
+	if flags&0x40 != 0 && version >= 3 { // 扩展头
+		if pos+4 > end {
+			return
+		}
+		extSize := int(binary.BigEndian.Uint32(data[pos:]))
+		if version == 4 {
+			extSize = syncsafe(data[pos : pos+4])
+		} else {
+			extSize += 4
+		}
+		pos += extSize
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for pos+headerLen <= end {
+		id := string(data[pos : pos+idLen])
+		if id[0] == 0 {
+			break // 填充区
+		}
+		var size int
+		switch version {
+		case 2:
+			size = int(data[pos+3])<<16 | int(data[pos+4])<<8 | int(data[pos+5])
+		case 4:
+			size = syncsafe(data[pos+4 : pos+8])
+		default:
+			size = int(binary.BigEndian.Uint32(data[pos+4:]))
+		}
+		body := pos + headerLen
+		if size <= 0 || body+size > end {
+			break
+		}
+		if key, ok := id3FrameNames[id]; ok {
+			if text := decodeID3Text(data[body : body+size]); text != "" {
+				if key == "year" && len(text) > 4 {
+					text = text[:4]
+				}
+				if key == "genre" {
+					text = normalizeID3Genre(text)
+				}
+				if key == "track" || key == "disc" { // "3/12" 只保留序号
+					text, _, _ = strings.Cut(text, "/")
+				}
+				out[key] = text
+			}
+		}
+		pos = body + size
+	}
+}
+
+func decodeID3Text(frame []byte) string {
+	if len(frame) < 1 {
+		return ""
+	}
+	enc, body := frame[0], frame[1:]
+	var s string
+	switch enc {
+	case 1, 2: // UTF-16 (带 BOM) / UTF-16BE
+		s = decodeUTF16(body, enc == 2)
+	case 3:
+		s = string(body)
+	default: // ISO-8859-1
+		runes := make([]rune, len(body))
+		for i, b := range body {
+			runes[i] = rune(b)
+		}
+		s = string(runes)
+	}
+	// 多值帧以 \x00 分隔，只取第一个
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFF && b[1] == 0xFE:
+			order, b = binary.LittleEndian, b[2:]
+		case b[0] == 0xFE && b[1] == 0xFF:
+			order, b = binary.BigEndian, b[2:]
+		}
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, order.Uint16(b[i:]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// normalizeID3Genre 把 "(17)" 或 "17" 这种数字流派去掉括号，保留原文。
+func normalizeID3Genre(s string) string {
+	if strings.HasPrefix(s, "(") {
+		if i := strings.IndexByte(s, ')'); i > 0 && i+1 < len(s) {
+			return s[i+1:]
+		}
+		return strings.Trim(s, "()")
+	}
+	return s
+}
+
+func parseID3v1(r io.ReadSeeker, out map[string]string) {
+	buf := make([]byte, 128)
+	if _, err := r.Seek(-128, io.SeekEnd); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf[:3]) != "TAG" {
+		return
+	}
+	field := func(b []byte) string {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		return strings.TrimSpace(string(b))
+	}
+	set := func(key, value string) {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	set("title", field(buf[3:33]))
+	set("artist", field(buf[33:63]))
+	set("album", field(buf[63:93]))
+	set("year", field(buf[93:97]))
+	if buf[125] == 0 && buf[126] != 0 { // ID3v1.1 音轨号
+		out["track"] = strconv.Itoa(int(buf[126]))
+	}
+}
+
+// --- FLAC ---
+
+var vorbisNames = map[string]string{
+	"TITLE": "title", "ARTIST": "artist", "ALBUMARTIST": "albumartist", "ALBUM": "album",
+	"DATE": "year", "YEAR": "year", "TRACKNUMBER": "track", "DISCNUMBER": "disc",
+	"GENRE": "genre", "COMPOSER": "composer",
+}
+
+func parseFLAC(data []byte, out map[string]string) {
+	pos := 4
+	for pos+4 <= len(data) {
+		header := data[pos]
+		last := header&0x80 != 0
+		blockType := header & 0x7F
+		size := int(data[pos+1])<<16 | int(data[pos+2])<<8 | int(data[pos+3])
+		body := pos + 4
+		if body+size > len(data) {
+			return
+		}
+		if blockType == 4 {
+			parseVorbisComment(data[body:body+size], out)
+			return
+		}
+		if last {
+			return
+		}
+		pos = body + size
+	}
+}
+
+func parseVorbisComment(b []byte, out map[string]string) {
+	if len(b) < 8 {
+		return
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(b))
+	pos := 4 + vendorLen
+	if pos+4 > len(b) {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(b[pos:]))
+	pos += 4
+	for i := 0; i < count && pos+4 <= len(b); i++ {
+		n := int(binary.LittleEndian.Uint32(b[pos:]))
+		pos += 4
+		if n < 0 || pos+n > len(b) {
+			return
+		}
+		comment := string(b[pos : pos+n])
+		pos += n
+		k, v, ok := strings.Cut(comment, "=")
+		if !ok {
+			continue
+		}
+		if key, known := vorbisNames[strings.ToUpper(k)]; known {
+			if _, exists := out[key]; !exists {
+				if key == "year" && len(v) > 4 {
+					v = v[:4]
+				}
+				if key == "track" || key == "disc" {
+					v, _, _ = strings.Cut(v, "/")
+				}
+				out[key] = strings.TrimSpace(v)
+			}
+		}
+	}
+}
+
+// --- MP4 / M4A ---
+
+var mp4AtomNames = map[string]string{
+	"\xa9nam": "title", "\xa9ART": "artist", "aART": "albumartist", "\xa9alb": "album",
+	"\xa9day": "year", "trkn": "track", "disk": "disc", "\xa9gen": "genre", "\xa9wrt": "composer",
+}
+
+// parseMP4 沿 moov/udta/meta/ilst 查找 iTunes 风格的标签。只读取 moov 盒子，跳过媒体数据。
+func parseMP4(r io.ReadSeeker, out map[string]string) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+	moov := findMP4Atom(r, 0, end, "moov")
+	if moov == nil || len(moov) > maxMetaRead {
+		return
+	}
+	for _, path := range [][]string{{"udta", "meta", "ilst"}, {"meta", "ilst"}} {
+		box := moov
+		for _, name := range path {
+			if name == "ilst" && len(box) >= 4 {
+				box = box[4:] // meta 是 full box，有 4 字节的版本和标志
+			}
+			box = childAtom(box, name)
+			if box == nil {
+				break
+			}
+		}
+		if box != nil {
+			readIlst(box, out)
+			return
+		}
+	}
+}
+
+func findMP4Atom(r io.ReadSeeker, start, end int64, name string) []byte {
+	header := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil
+		}
+		size := uint64(binary.BigEndian.Uint32(header))
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(end - pos)
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil
+			}
+			size = binary.BigEndian.Uint64(header[8:])
+			headerLen = 16
+		}
+		// 大小来自文件本身，先确认它在文件范围内，损坏的文件不能导致巨大的分配或溢出
+		if size < headerLen || size > uint64(end-pos) {
+			return nil
+		}
+		if string(header[4:8]) == name {
+			if size-headerLen > maxMetaRead {
+				return nil
+			}
+			body := make([]byte, size-headerLen)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil
+			}
+			return body
+		}
+		pos += int64(size)
+	}
+	return nil
+}
+
+func childAtom(b []byte, name string) []byte {
+	for pos := 0; pos+8 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[pos:]))
+		if size < 8 || pos+size > len(b) {
+			return nil
+		}
+		if string(b[pos+4:pos+8]) == name {
+			return b[pos+8 : pos+size]
+		}
+		pos += size
+	}
+	return nil
+}
+
+func readIlst(b []byte, out map[string]string) {
+	for pos := 0; pos+8 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[pos:]))
+		if size < 8 || pos+size > len(b) {
+			return
+		}
+		name := string(b[pos+4 : pos+8])
+		if key, ok := mp4AtomNames[name]; ok {
+			data := childAtom(b[pos+8:pos+size], "data")
+			if len(data) > 8 {
+				payload := data[8:] // 跳过 4 字节类型 + 4 字节区域
+				switch key {
+				case "track", "disc":
+					if len(payload) >= 4 {
+						out[key] = strconv.Itoa(int(binary.BigEndian.Uint16(payload[2:])))
+					}
+				default:
+					text := strings.TrimSpace(string(payload))
+					if key == "year" && len(text) > 4 {
+						text = text[:4]
+					}
+					if text != "" {
+						out[key] = text
+					}
+				}
+			}
+		}
+		pos += size
+	}
+}
+
+// parseExifTime 把 EXIF 日期字符串转换为 time.Time，供模板中的日期格式使用。
+func parseExifTime(s string) (time.Time, bool) {
+	t, err := time.ParseInLocation(exifDateLayout, s, time.Local)
+	return t, err == nil
+}
@@ -0,0 +1,616 @@
+package renamer_tool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// --- EXIF ---
+
+// tiffEntry 是测试用 IFD 中的一项，value 超过 4 字节时写到 IFD 之后并记录偏移
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffOrder 是 binary.LittleEndian 或 binary.BigEndian
+type tiffOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// testTIFF 生成包含 IFD0 和 Exif 子 IFD 的 TIFF 数据
+func testTIFF(order tiffOrder, ifd0, exif []tiffEntry) []byte {
+	buf := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(buf, "II*\x00")
+	} else {
+		copy(buf, "MM\x00*")
+	}
+	order.PutUint32(buf[4:], 8)
+
+	writeIFD := func(entries []tiffEntry) {
+		start := len(buf)
+		extra := start + 2 + 12*len(entries) + 4
+		var tail []byte
+		buf = order.AppendUint16(buf, uint16(len(entries)))
+		for _, e := range entries {
+			buf = order.AppendUint16(buf, e.tag)
+			buf = order.AppendUint16(buf, e.typ)
+			buf = order.AppendUint32(buf, e.count)
+			if len(e.value) <= 4 {
+				buf = append(buf, append(e.value, make([]byte, 4-len(e.value))...)...)
+			} else {
+				buf = order.AppendUint32(buf, uint32(extra+len(tail)))
+				tail = append(tail, e.value...)
+			}
+		}
+		buf = append(order.AppendUint32(buf, 0), tail...)
+	}
+	if exif != nil {
+		// IFD0 的最后一项指向紧跟在 IFD0 之后的 Exif IFD
+		size := 2 + 12*(len(ifd0)+1) + 4
+		for _, e := range ifd0 {
+			if len(e.value) > 4 {
+				size += len(e.value)
+			}
+		}
+		ptr := order.AppendUint32(nil, uint32(8+size))
+		ifd0 = append(ifd0, tiffEntry{exifIFDPointer, 4, 1, ptr})
+	}
+	writeIFD(ifd0)
+	if exif != nil {
+		writeIFD(exif)
+	}
+	return buf
+}
+
+func ascii(s string) tiffEntry {
+	return tiffEntry{value: append([]byte(s), 0), typ: 2, count: uint32(len(s) + 1)}
+}
+
+func tagged(tag uint16, e tiffEntry) tiffEntry { e.tag = tag; return e }
+
+func rational(order tiffOrder, num, den uint32) tiffEntry {
+	return tiffEntry{typ: 5, count: 1, value: order.AppendUint32(order.AppendUint32(nil, num), den)}
+}
+
+func TestParseExif(t *testing.T) {
+	for _, order := range []tiffOrder{binary.LittleEndian, binary.BigEndian} {
+		tiff := testTIFF(order,
+			[]tiffEntry{
+				tagged(0x010F, ascii("Canon")),
+				tagged(0x0110, ascii("EOS")),
+				{tag: 0x0112, typ: 3, count: 1, value: order.AppendUint16(nil, 6)},
+				{tag: 0x1234, typ: 4, count: 1, value: order.AppendUint32(nil, 7)},
+			},
+			[]tiffEntry{
+				tagged(0x9003, ascii("2024:05:06 07:08:09")),
+				tagged(0x829A, rational(order, 1, 250)),
+				tagged(0x829D, rational(order, 28, 10)),
+				{tag: 0x9204, typ: 10, count: 1, value: order.AppendUint32(order.AppendUint32(nil, uint32(0xFFFFFFFF)), 3)}, // -1/3
+				{tag: 0x8827, typ: 3, count: 1, value: order.AppendUint16(nil, 400)},
+				{tag: 0x9999, typ: 12, count: 1, value: make([]byte, 8)}, // 不支持的类型被忽略
+			},
+		)
+		want := map[string]string{
+			"Make":             "Canon",
+			"Model":            "EOS",
+			"Orientation":      "6",
+			"0x1234":           "7",
+			"DateTimeOriginal": "2024:05:06 07:08:09",
+			"ExposureTime":     "1/250",
+			"FNumber":          "2.8",
+			"0x9204":           "-0.3333333333333333",
+			"ISOSpeedRatings":  "400",
+		}
+		got := map[string]string{}
+		parseExif(tiff, got)
+		if !maps.Equal(got, want) {
+			t.Errorf("%v: parseExif() = %v, 期望 %v", order, got, want)
+		}
+
+		// JPEG 中的 APP1 段，前面可能有其他段
+		jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 4, 'J', 'F'}
+		app1 := append([]byte("Exif\x00\x00"), tiff...)
+		jpeg = append(jpeg, 0xFF, 0xE1, byte((len(app1)+2)>>8), byte(len(app1)+2))
+		jpeg = append(jpeg, app1...)
+		if got := findJPEGExif(jpeg); !bytes.Equal(got, tiff) {
+			t.Errorf("%v: findJPEGExif() 没有找到 Exif 段", order)
+		}
+		// 任意截断都不能越界
+		for n := range len(jpeg) {
+			if tiff := findJPEGExif(jpeg[:n]); tiff != nil {
+				parseExif(tiff, map[string]string{})
+			}
+			parseExif(jpeg[:n], map[string]string{})
+		}
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{name: "太短", data: []byte("II*\x00"), want: map[string]string{}},
+		{name: "未知字节序", data: []byte("XX*\x00\x08\x00\x00\x00\x00\x00"), want: map[string]string{}},
+		{name: "IFD 偏移超出范围", data: []byte("II*\x00\xff\xff\xff\x7f"), want: map[string]string{}},
+		{
+			name: "值的偏移超出范围时跳过",
+			data: func() []byte {
+				tiff := testTIFF(binary.LittleEndian, []tiffEntry{tagged(0x010F, ascii("Nikon Corp")), tagged(0x0110, ascii("D7"))}, nil)
+				binary.LittleEndian.PutUint32(tiff[8+2+8:], 1<<20)
+				return tiff
+			}(),
+			want: map[string]string{"Model": "D7"},
+		},
+		{
+			name: "数量过大时跳过",
+			data: testTIFF(binary.LittleEndian, []tiffEntry{{tag: 0x010F, typ: 2, count: 1 << 20, value: []byte("abcd")}}, nil),
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			parseExif(tt.data, got)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseExif() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindJPEGExif(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "SOS 之后不再查找", data: []byte{0xFF, 0xD8, 0xFF, 0xDA, 0, 2, 0xFF, 0xE1, 0, 8, 'E', 'x', 'i', 'f', 0, 0}},
+		{name: "段长度小于 2", data: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 1, 0, 0}},
+		{name: "段长度超出数据", data: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E', 'x', 'i', 'f', 0, 0}},
+		{name: "不是标记", data: []byte{0xFF, 0xD8, 0x00, 0xE1, 0, 8, 'E', 'x', 'i', 'f', 0, 0}},
+		{name: "APP1 不是 Exif", data: []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 6, 'h', 't', 't', 'p'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findJPEGExif(tt.data); got != nil {
+				t.Errorf("findJPEGExif() = %q, 期望 nil", got)
+			}
+		})
+	}
+}
+
+func TestParseExifTime(t *testing.T) {
+	got, ok := parseExifTime("2024:05:06 07:08:09")
+	if !ok || !got.Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)) {
+		t.Errorf("parseExifTime() = %v, %v", got, ok)
+	}
+	if _, ok := parseExifTime("2024-05-06"); ok {
+		t.Error("格式不对时期望失败")
+	}
+}
+
+// --- ID3 ---
+
+// id3Frame 生成一个帧，version 决定帧头的长度和大小的写法
+func id3Frame(version byte, id string, body []byte) []byte {
+	var head []byte
+	switch version {
+	case 2:
+		n := len(body)
+		head = append([]byte(id), byte(n>>16), byte(n>>8), byte(n))
+	case 4:
+		head = append([]byte(id), syncsafeBytes(len(body))...)
+		head = append(head, 0, 0)
+	default:
+		head = binary.BigEndian.AppendUint32([]byte(id), uint32(len(body)))
+		head = append(head, 0, 0)
+	}
+	return append(head, body...)
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// id3Tag 生成 ID3v2 标签，padding 是标签末尾的填充字节数
+func id3Tag(version, flags byte, padding int, frames ...[]byte) []byte {
+	body := append(bytes.Join(frames, nil), make([]byte, padding)...)
+	head := append([]byte{'I', 'D', '3', version, 0, flags}, syncsafeBytes(len(body))...)
+	return append(head, body...)
+}
+
+func TestParseID3v2(t *testing.T) {
+	latin1 := func(s string) []byte { return append([]byte{0}, s...) }
+	utf8 := func(s string) []byte { return append([]byte{3}, s...) }
+	utf16le := []byte{1, 0xFF, 0xFE, 0x2D, 0x4E, 0x87, 0x65} // "中文"
+	utf16be := []byte{2, 0x4E, 0x2D, 0x65, 0x87}
+
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{
+			name: "v2.3",
+			data: id3Tag(3, 0, 16,
+				id3Frame(3, "TIT2", latin1("Caf\xe9")),
+				id3Frame(3, "TPE1", utf16le),
+				id3Frame(3, "TYER", latin1("2021-05-04")),
+				id3Frame(3, "TRCK", latin1("3/12")),
+				id3Frame(3, "TCON", latin1("(17)Rock")),
+				id3Frame(3, "COMM", latin1("ignored")),
+			),
+			want: map[string]string{"title": "Café", "artist": "中文", "year": "2021", "track": "3", "genre": "Rock"},
+		},
+		{
+			name: "v2.4 使用 syncsafe 帧大小",
+			data: id3Tag(4, 0, 0,
+				id3Frame(4, "TALB", utf8(string(bytes.Repeat([]byte("a"), 200)))),
+				id3Frame(4, "TPOS", utf16be),
+				id3Frame(4, "TCON", latin1("(17)")),
+			),
+			want: map[string]string{"album": string(bytes.Repeat([]byte("a"), 200)), "disc": "中文", "genre": "17"},
+		},
+		{
+			name: "v2.2 三字节帧头",
+			data: id3Tag(2, 0, 0, id3Frame(2, "TT2", latin1("Old")), id3Frame(2, "TP1", latin1("Band"))),
+			want: map[string]string{"title": "Old", "artist": "Band"},
+		},
+		{
+			name: "v2.3 扩展头",
+			data: id3Tag(3, 0x40, 0, []byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0}, id3Frame(3, "TIT2", latin1("Ext"))),
+			want: map[string]string{"title": "Ext"},
+		},
+		{
+			name: "v2.4 扩展头",
+			data: id3Tag(4, 0x40, 0, []byte{0, 0, 0, 6, 1, 0}, id3Frame(4, "TIT2", latin1("Ext4"))),
+			want: map[string]string{"title": "Ext4"},
+		},
+		{
+			name: "多值帧只取第一个",
+			data: id3Tag(4, 0, 0, id3Frame(4, "TPE1", utf8("A\x00B"))),
+			want: map[string]string{"artist": "A"},
+		},
+		{
+			name: "帧超出标签时停止",
+			data: id3Tag(3, 0, 0, id3Frame(3, "TIT2", latin1("ok")), id3Frame(3, "TALB", latin1("cut"))[:12]),
+			want: map[string]string{"title": "ok"},
+		},
+		{
+			name: "大小为 0 的帧",
+			data: id3Tag(3, 0, 0, id3Frame(3, "TIT2", nil), id3Frame(3, "TALB", latin1("after"))),
+			want: map[string]string{},
+		},
+		{name: "太短", data: []byte("ID3\x03\x00"), want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			parseID3v2(tt.data, got)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseID3v2() = %q, 期望 %q", got, tt.want)
+			}
+			// 标签头声明的大小大于实际数据时按实际数据截断
+			for n := range len(tt.data) {
+				parseID3v2(tt.data[:n], map[string]string{})
+			}
+		})
+	}
+}
+
+func TestParseID3v1(t *testing.T) {
+	tag := func(title, artist string, track byte) []byte {
+		buf := make([]byte, 128)
+		copy(buf, "TAG")
+		copy(buf[3:], title)
+		copy(buf[33:], artist)
+		copy(buf[63:], "Album   ")
+		copy(buf[93:], "1999")
+		buf[126] = track
+		return buf
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{
+			name: "v1.1 带音轨号",
+			data: append(make([]byte, 300), tag("Song", "", 7)...),
+			want: map[string]string{"title": "Song", "album": "Album", "year": "1999", "track": "7"},
+		},
+		{
+			name: "v1.0",
+			data: tag("Song", "Artist", 0),
+			want: map[string]string{"title": "Song", "artist": "Artist", "album": "Album", "year": "1999"},
+		},
+		{name: "没有 TAG", data: make([]byte, 200), want: map[string]string{}},
+		{name: "文件太短", data: []byte("TAG"), want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			parseID3v1(bytes.NewReader(tt.data), got)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseID3v1() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// --- FLAC ---
+
+func vorbisComment(comments ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, 6)
+	b = append(b, "vendor"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, c := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c)))
+		b = append(b, c...)
+	}
+	return b
+}
+
+func flacBlock(typ byte, last bool, body []byte) []byte {
+	if last {
+		typ |= 0x80
+	}
+	n := len(body)
+	return append([]byte{typ, byte(n >> 16), byte(n >> 8), byte(n)}, body...)
+}
+
+func TestParseFLAC(t *testing.T) {
+	comment := vorbisComment("title=Song", "ARTIST=A", "Artist=B", "DATE=2020-01-02", "TRACKNUMBER=4/10", "NOEQUALS", "COMMENT=x")
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{
+			name: "STREAMINFO 之后的 VORBIS_COMMENT",
+			data: bytes.Join([][]byte{[]byte("fLaC"), flacBlock(0, false, make([]byte, 34)), flacBlock(4, true, comment)}, nil),
+			want: map[string]string{"title": "Song", "artist": "A", "year": "2020", "track": "4"},
+		},
+		{
+			name: "最后一个块之后不再查找",
+			data: bytes.Join([][]byte{[]byte("fLaC"), flacBlock(0, true, make([]byte, 34)), flacBlock(4, true, comment)}, nil),
+			want: map[string]string{},
+		},
+		{
+			name: "块超出数据",
+			data: append([]byte("fLaC"), flacBlock(4, true, comment)[:20]...),
+			want: map[string]string{},
+		},
+		{
+			name: "注释长度超出块时停止",
+			data: append([]byte("fLaC"), flacBlock(4, true, append(vorbisComment("TITLE=ok"), 0xFF, 0xFF, 0xFF, 0x7F))...),
+			want: map[string]string{"title": "ok"},
+		},
+		{
+			name: "vendor 长度超出块",
+			data: append([]byte("fLaC"), flacBlock(4, true, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})...),
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			parseFLAC(tt.data, got)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseFLAC() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// --- MP4 ---
+
+func atom(name string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(body))), append([]byte(name), body...)...)
+}
+
+func dataAtom(payload []byte) []byte {
+	return atom("data", make([]byte, 8), payload)
+}
+
+func TestParseMP4(t *testing.T) {
+	ilst := atom("ilst",
+		atom("\xa9nam", dataAtom([]byte("Title"))),
+		atom("\xa9day", dataAtom([]byte("2019-03-01T00:00:00Z"))),
+		atom("trkn", dataAtom([]byte{0, 0, 0, 5, 0, 12, 0, 0})),
+		atom("disk", dataAtom([]byte{0, 0})), // 太短，忽略
+		atom("\xa9cmt", dataAtom([]byte("ignored"))),
+	)
+	meta := atom("meta", make([]byte, 4), atom("hdlr", make([]byte, 20)), ilst)
+	ftyp := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	mdat := atom("mdat", make([]byte, 1000))
+	want := map[string]string{"title": "Title", "year": "2019", "track": "5"}
+
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{name: "moov/udta/meta/ilst", data: bytes.Join([][]byte{ftyp, mdat, atom("moov", atom("mvhd", make([]byte, 100)), atom("udta", meta))}, nil), want: want},
+		{name: "moov/meta/ilst", data: bytes.Join([][]byte{ftyp, atom("moov", meta)}, nil), want: want},
+		{name: "没有 moov", data: bytes.Join([][]byte{ftyp, mdat}, nil), want: map[string]string{}},
+		{
+			name: "64 位大小的 mdat",
+			data: bytes.Join([][]byte{
+				ftyp,
+				binary.BigEndian.AppendUint64([]byte{0, 0, 0, 1, 'm', 'd', 'a', 't'}, 16+4), make([]byte, 4),
+				atom("moov", meta),
+			}, nil),
+			want: want,
+		},
+		{
+			name: "大小为 0 的最后一个盒子延伸到文件末尾",
+			data: bytes.Join([][]byte{ftyp, {0, 0, 0, 0, 'm', 'o', 'o', 'v'}, meta}, nil),
+			want: want,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			parseMP4(bytes.NewReader(tt.data), got)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseMP4() = %q, 期望 %q", got, tt.want)
+			}
+			for n := range len(tt.data) {
+				parseMP4(bytes.NewReader(tt.data[:n]), map[string]string{})
+			}
+		})
+	}
+}
+
+// hugeFile 声明一个很大的长度，但只有开头的数据，读取其他位置返回 EOF。
+// 用来确认损坏的盒子大小不会导致按声明的大小分配内存。
+type hugeFile struct {
+	head []byte
+	size int64
+	pos  int64
+}
+
+func (f *hugeFile) Read(p []byte) (int, error) {
+	if f.pos >= int64(len(f.head)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.head[f.pos:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *hugeFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.size
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func TestFindMP4AtomBounds(t *testing.T) {
+	largeHeader := func(size uint64) []byte {
+		return binary.BigEndian.AppendUint64([]byte{0, 0, 0, 1, 'm', 'o', 'o', 'v'}, size)
+	}
+	tests := []struct {
+		name string
+		head []byte
+		size int64
+	}{
+		{name: "32 位大小超出文件", head: []byte{0xFF, 0xFF, 0xFF, 0xFF, 'm', 'o', 'o', 'v'}, size: 64},
+		{name: "64 位大小超出文件", head: largeHeader(1 << 62), size: 64},
+		{name: "64 位大小溢出 int64", head: largeHeader(1<<64 - 1), size: 64},
+		{name: "大小小于头部", head: []byte{0, 0, 0, 4, 'm', 'o', 'o', 'v'}, size: 64},
+		{name: "64 位大小小于头部", head: largeHeader(8), size: 64},
+		{name: "在文件范围内但超过读取上限", head: largeHeader(maxMetaRead + 17), size: 1 << 40},
+		{name: "跳过的盒子大小超出文件", head: append([]byte{0x7F, 0xFF, 0xFF, 0xFF, 'm', 'd', 'a', 't'}, atom("moov")...), size: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findMP4Atom(&hugeFile{head: tt.head, size: tt.size}, 0, tt.size, "moov"); got != nil {
+				t.Errorf("findMP4Atom() 返回了 %d 字节, 期望 nil", len(got))
+			}
+		})
+	}
+
+	// 正常的盒子不受影响
+	data := append(atom("free", make([]byte, 10)), atom("moov", []byte("body"))...)
+	if got := findMP4Atom(bytes.NewReader(data), 0, int64(len(data)), "moov"); string(got) != "body" {
+		t.Errorf("findMP4Atom() = %q, 期望 \"body\"", got)
+	}
+}
+
+func TestChildAtomAndReadIlst(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "大小小于头部", data: []byte{0, 0, 0, 2, 'd', 'a', 't', 'a'}},
+		{name: "大小超出数据", data: []byte{0, 0, 0, 99, 'd', 'a', 't', 'a'}},
+		{name: "截断的头部", data: []byte{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childAtom(tt.data, "data"); got != nil {
+				t.Errorf("childAtom() = %q, 期望 nil", got)
+			}
+			out := map[string]string{}
+			readIlst(tt.data, out)
+			if len(out) != 0 {
+				t.Errorf("readIlst() = %q", out)
+			}
+		})
+	}
+}
+
+// --- loadMetadata ---
+
+func TestLoadMetadata(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 3, 2)))
+
+	id3v1 := make([]byte, 128)
+	copy(id3v1, "TAGOnly v1")
+
+	tiff := testTIFF(binary.BigEndian, []tiffEntry{tagged(0x0110, ascii("Model X"))}, nil)
+	tests := []struct {
+		name      string
+		path      string
+		wantWidth int
+		wantExif  map[string]string
+		wantTags  map[string]string
+	}{
+		{name: "PNG 尺寸", path: write("a.png", img.Bytes()), wantWidth: 3},
+		{name: "TIFF", path: write("a.tif", tiff), wantExif: map[string]string{"Model": "Model X"}},
+		{name: "ID3v2", path: write("a.mp3", id3Tag(3, 0, 0, id3Frame(3, "TIT2", []byte("\x00v2")))), wantTags: map[string]string{"title": "v2"}},
+		{name: "只有 ID3v1 的 MP3", path: write("b.MP3", append(make([]byte, 50), id3v1...)), wantTags: map[string]string{"title": "Only v1"}},
+		{name: "其他扩展名不读取 ID3v1", path: write("b.bin", append(make([]byte, 50), id3v1...))},
+		{name: "FLAC", path: write("a.flac", append([]byte("fLaC"), flacBlock(4, true, vorbisComment("TITLE=F"))...)), wantTags: map[string]string{"title": "F"}},
+		{name: "空文件", path: write("empty.mp3", nil)},
+		{name: "文件不存在", path: filepath.Join(dir, "missing.jpg")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := loadMetadata(tt.path)
+			if m.Width != tt.wantWidth {
+				t.Errorf("Width = %d, 期望 %d", m.Width, tt.wantWidth)
+			}
+			if tt.wantExif == nil {
+				tt.wantExif = map[string]string{}
+			}
+			if tt.wantTags == nil {
+				tt.wantTags = map[string]string{}
+			}
+			if !maps.Equal(m.Exif, tt.wantExif) {
+				t.Errorf("Exif = %q, 期望 %q", m.Exif, tt.wantExif)
+			}
+			if !maps.Equal(m.Tags, tt.wantTags) {
+				t.Errorf("Tags = %q, 期望 %q", m.Tags, tt.wantTags)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package renamer_tool
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lascape/sat"
+	"github.com/mozillazg/go-pinyin"
+)
+
+// PinyinRule 把名称中的汉字转换为拼音，其他字符保持不变。
+// 可以先用文本格式化工具相同的 sat 字典把繁体转为简体。
+type PinyinRule struct {
+	ruleBase
+	Tone      string // "none" 不带声调, "mark" 声调符号 (zhōng), "number" 数字声调 (zhong1)
+	Initials  bool   // 只保留每个字的首字母
+	Separator string // 音节之间，以及音节与相邻字母、数字之间的分隔符
+	Simplify  bool   // 转换前先把繁体字转为简体
+}
+
+var pinyinTones = []string{"none", "mark", "number"}
+var pinyinToneLabels = []string{"不带声调", "声调符号 (zhōng)", "数字声调 (zhong1)"}
+
+var (
+	satOnce sync.Once
+	satDict sat.Dicter
+)
+
+// simplified 把繁体字转为简体。字典只在第一次使用时加载。
+func simplified(text string) string {
+	satOnce.Do(func() { satDict = sat.DefaultDict() })
+	return satDict.Read(text)
+}
+
+func (r *PinyinRule) Apply(original string, item *FileItem, index int) string {
+	if r.Simplify {
+		original = simplified(original)
+	}
+	args := pinyin.NewArgs()
+	switch {
+	case r.Initials:
+		args.Style = pinyin.FirstLetter
+	case r.Tone == "mark":
+		args.Style = pinyin.Tone
+	case r.Tone == "number":
+		args.Style = pinyin.Tone3
+	}
+
+	var sb strings.Builder
+	prevSyllable, prevWord := false, false // 上一个输出是拼音音节 / 字母或数字
+	for _, c := range original {
+		var py []string
+		if unicode.Is(unicode.Han, c) {
+			py = pinyin.SinglePinyin(c, args)
+		}
+		if len(py) == 0 {
+			isWord := unicode.IsLetter(c) || unicode.IsDigit(c)
+			if prevSyllable && isWord {
+				sb.WriteString(r.Separator)
+			}
+			sb.WriteRune(c)
+			prevSyllable, prevWord = false, isWord
+			continue
+		}
+		if prevSyllable || prevWord {
+			sb.WriteString(r.Separator)
+		}
+		sb.WriteString(py[0])
+		prevSyllable, prevWord = true, false
+	}
+	return sb.String()
+}
+
+func (r *PinyinRule) Describe() string {
+	desc := "全拼"
+	if r.Initials {
+		desc = "首字母"
+	} else {
+		for i, t := range pinyinTones {
+			if t == r.Tone {
+				desc += ", " + pinyinToneLabels[i]
+			}
+		}
+	}
+	if r.Separator != "" {
+		desc += fmt.Sprintf(", 分隔符 '%s'", r.Separator)
+	}
+	if r.Simplify {
+		desc += ", 繁转简"
+	}
+	return fmt.Sprintf("拼音: %s", desc)
+}
@@ -0,0 +1,428 @@
+package renamer_tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// presetVersion 是当前的预设文件格式版本。
+// 版本 1 是早期直接保存 []RuleDefinition 的数组格式，加载时会自动迁移。
+const presetVersion = 2
+
+// Preset 是一个预设文件的内容
+type Preset struct {
+	Version int          `json:"version"`
+	Name    string       `json:"name,omitempty"`
+	Rules   []PresetRule `json:"rules"`
+}
+
+// PresetRule 描述一条规则，参数按名称保存并带有 JSON 类型 (字符串、数字、布尔值)
+type PresetRule struct {
+	Type     string         `json:"type"`
+	Disabled bool           `json:"disabled,omitempty"`
+	Scope    string         `json:"scope,omitempty"`
+	Params   map[string]any `json:"params"`
+}
+
+// RuleDefinition 是版本 1 预设中的规则格式，只用于迁移旧文件
+type RuleDefinition struct {
+	Type, Param1, Param2, Param3, Param4 string
+	Scope                                string `json:",omitempty"`
+	Flags                                string `json:",omitempty"` // 逗号分隔的附加选项
+	Disabled                             bool   `json:",omitempty"`
+}
+
+// parsePreset 解析预设文件内容。旧的数组格式会被转换为当前格式，此时 migrated 为 true。
+func parsePreset(data []byte) (preset Preset, migrated bool, err error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var defs []RuleDefinition
+		if err := json.Unmarshal(data, &defs); err != nil {
+			return Preset{}, false, fmt.Errorf("无法解析旧版预设: %v", err)
+		}
+		preset, err = migrateLegacyPreset(defs)
+		return preset, true, err
+	}
+	if err := json.Unmarshal(data, &preset); err != nil {
+		return Preset{}, false, fmt.Errorf("无法解析预设: %v", err)
+	}
+	if preset.Version < 2 {
+		return Preset{}, false, fmt.Errorf("缺少或无效的预设版本号: %d", preset.Version)
+	}
+	if preset.Version > presetVersion {
+		return Preset{}, false, fmt.Errorf("预设版本 %d 高于当前支持的版本 %d，请升级程序", preset.Version, presetVersion)
+	}
+	return preset, false, nil
+}
+
+// BuildRules 校验预设中的每条规则并创建规则实例，所有问题会汇总在一个错误中返回
+func (p Preset) BuildRules() ([]Rule, error) {
+	var rules []Rule
+	var problems []string
+	for i, pr := range p.Rules {
+		rule, err := ruleFromPreset(pr)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("第 %d 条规则 (%s): %v", i+1, pr.Type, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return rules, nil
+}
+
+func newPreset(name string, rules []Rule) Preset {
+	p := Preset{Version: presetVersion, Name: name, Rules: []PresetRule{}}
+	for _, r := range rules {
+		p.Rules = append(p.Rules, presetFromRule(r))
+	}
+	return p
+}
+
+func readPresetFile(path string) (Preset, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Preset{}, false, err
+	}
+	return parsePreset(data)
+}
+
+func writePresetFile(path string, p Preset) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("无法序列化规则: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("无法保存预设文件: %v", err)
+	}
+	return nil
+}
+
+// --- 规则与预设之间的转换 ---
+
+func ruleFromPreset(pr PresetRule) (Rule, error) {
+	params := &paramReader{params: pr.Params}
+	var rule Rule
+	switch pr.Type {
+	case "replace":
+		rule = &ReplaceRule{Old: params.requiredString("old"), New: params.String("new", "")}
+	case "insert":
+		rule = &InsertRule{Text: params.String("text", ""), Position: params.position("position")}
+	case "case":
+		rule = &CaseRule{
+			CaseType:   params.Enum("case", "lower", caseTypes...),
+			SmallWords: splitList(params.String("small_words", strings.Join(defaultSmallWords, ","))),
+			Separators: params.Bool("separators", false),
+		}
+	case "serialize":
+		rule = &SerializeRule{
+			Start:     params.Int("start", 1),
+			Step:      params.Int("step", 1),
+			Padding:   params.Int("padding", 0),
+			Position:  params.position("position"),
+			PerFolder: params.Bool("per_folder", false),
+			Natural:   params.Bool("natural", false),
+			CountDown: params.Bool("count_down", false),
+			Format:    params.Enum("format", "", serializeFormats...),
+		}
+	case "template":
+		rule = &TemplateRule{Template: params.requiredString("template")}
+	case "mapping":
+		rule = &MappingRule{ByKey: params.Bool("by_key", false), Pairs: params.pairs("mappings")}
+	case "folder":
+		rule = &FolderRule{Template: params.requiredString("template"), Base: params.String("base", "")}
+	case "pinyin":
+		rule = &PinyinRule{
+			Tone:      params.Enum("tone", "none", pinyinTones...),
+			Initials:  params.Bool("initials", false),
+			Separator: params.String("separator", ""),
+			Simplify:  params.Bool("simplify", false),
+		}
+	case "extension":
+		rule = &ExtensionRule{Mode: params.Enum("mode", "set", "set", "lower", "upper"), NewExt: params.String("ext", "")}
+	case "":
+		return nil, errors.New("缺少规则类型")
+	default:
+		return nil, fmt.Errorf("未知的规则类型: %s", pr.Type)
+	}
+	if err := params.Err(); err != nil {
+		return nil, err
+	}
+
+	scope := ScopeName
+	if pr.Scope != "" {
+		found := false
+		for i, key := range scopeKeys {
+			if key == pr.Scope {
+				scope, found = RuleScope(i), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知的作用范围: %s", pr.Scope)
+		}
+	}
+	switch rule.(type) {
+	case *ExtensionRule:
+		scope = ScopeExt
+	case *FolderRule:
+		scope = ScopeFull
+	}
+	rule.options().Scope = scope
+	rule.options().Disabled = pr.Disabled
+	return rule, nil
+}
+
+func presetFromRule(rule Rule) PresetRule {
+	pr := PresetRule{Disabled: rule.options().Disabled}
+	if scope := rule.options().Scope; scope != ScopeName {
+		pr.Scope = scopeKeys[scope]
+	}
+	switch r := rule.(type) {
+	case *ReplaceRule:
+		pr.Type, pr.Params = "replace", map[string]any{"old": r.Old, "new": r.New}
+	case *InsertRule:
+		pr.Type, pr.Params = "insert", map[string]any{"text": r.Text, "position": positionName(r.Position)}
+	case *CaseRule:
+		pr.Type, pr.Params = "case", map[string]any{
+			"case": r.CaseType, "small_words": strings.Join(r.SmallWords, ","), "separators": r.Separators,
+		}
+	case *SerializeRule:
+		pr.Type, pr.Params = "serialize", map[string]any{
+			"start": r.Start, "step": r.Step, "padding": r.Padding, "position": positionName(r.Position),
+			"per_folder": r.PerFolder, "natural": r.Natural, "count_down": r.CountDown, "format": r.Format,
+		}
+	case *TemplateRule:
+		pr.Type, pr.Params = "template", map[string]any{"template": r.Template}
+	case *MappingRule:
+		mappings := make([]any, len(r.Pairs))
+		for i, p := range r.Pairs {
+			if r.ByKey {
+				mappings[i] = []any{p.Key, p.Value}
+			} else {
+				mappings[i] = p.Value
+			}
+		}
+		pr.Type, pr.Params = "mapping", map[string]any{"by_key": r.ByKey, "mappings": mappings}
+	case *FolderRule:
+		pr.Type, pr.Params = "folder", map[string]any{"template": r.Template, "base": r.Base}
+		pr.Scope = ""
+	case *PinyinRule:
+		pr.Type, pr.Params = "pinyin", map[string]any{
+			"tone": r.Tone, "initials": r.Initials, "separator": r.Separator, "simplify": r.Simplify,
+		}
+	case *ExtensionRule:
+		pr.Type, pr.Params = "extension", map[string]any{"mode": r.Mode, "ext": r.NewExt}
+		pr.Scope = ""
+	}
+	return pr
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(text string) []string {
+	var list []string
+	for _, s := range strings.Split(text, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func positionName(pos int) string {
+	if pos == -1 {
+		return "suffix"
+	}
+	return "prefix"
+}
+
+// cloneRule 通过预设格式做一次往返，得到规则的深拷贝
+func cloneRule(rule Rule) (Rule, error) {
+	return ruleFromPreset(presetFromRule(rule))
+}
+
+// migrateLegacyPreset 把版本 1 的 Param1..Param4 字符串参数转换为具名参数。
+// 旧版本会静默忽略无法解析的数字，这里改为报告错误。
+func migrateLegacyPreset(defs []RuleDefinition) (Preset, error) {
+	p := Preset{Version: presetVersion, Rules: []PresetRule{}}
+	var problems []string
+	atoi := func(i int, name, s string) int {
+		if s == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("第 %d 条规则的 %s 不是数字: '%s'", i+1, name, s))
+		}
+		return n
+	}
+	for i, def := range defs {
+		pr := PresetRule{Type: def.Type, Disabled: def.Disabled, Scope: def.Scope}
+		switch def.Type {
+		case "replace":
+			pr.Params = map[string]any{"old": def.Param1, "new": def.Param2}
+		case "insert":
+			pr.Params = map[string]any{"text": def.Param1, "position": positionName(atoi(i, "位置", def.Param2))}
+		case "case":
+			pr.Params = map[string]any{"case": def.Param1}
+		case "serialize":
+			params := map[string]any{
+				"start": atoi(i, "起始数字", def.Param1), "step": atoi(i, "步长", def.Param2),
+				"padding": atoi(i, "补零位数", def.Param3), "position": positionName(atoi(i, "位置", def.Param4)),
+				"per_folder": false, "natural": false, "count_down": false, "format": "",
+			}
+			for _, flag := range strings.Split(def.Flags, ",") {
+				switch flag {
+				case "perfolder":
+					params["per_folder"] = true
+				case "natural":
+					params["natural"] = true
+				case "countdown":
+					params["count_down"] = true
+				case "lower", "upper", "roman", "roman-lower":
+					params["format"] = flag
+				}
+			}
+			pr.Params = params
+		case "template":
+			pr.Params = map[string]any{"template": def.Param1}
+		case "extension":
+			pr.Params = map[string]any{"mode": def.Param1, "ext": def.Param2}
+		default:
+			problems = append(problems, fmt.Sprintf("第 %d 条规则的类型未知: '%s'", i+1, def.Type))
+			continue
+		}
+		p.Rules = append(p.Rules, pr)
+	}
+	if len(problems) > 0 {
+		return Preset{}, errors.New(strings.Join(problems, "\n"))
+	}
+	return p, nil
+}
+
+// paramReader 按名称读取规则参数并校验类型，遇到的问题会累积起来由 Err 一并返回
+type paramReader struct {
+	params   map[string]any
+	problems []string
+}
+
+func (r *paramReader) fail(format string, args ...any) {
+	r.problems = append(r.problems, fmt.Sprintf(format, args...))
+}
+
+func (r *paramReader) String(key, def string) string {
+	v, ok := r.params[key]
+	if !ok || v == nil {
+		return def
+	}
+	s, ok := v.(string)
+	if !ok {
+		r.fail("参数 %s 应为字符串", key)
+		return def
+	}
+	return s
+}
+
+func (r *paramReader) requiredString(key string) string {
+	if _, ok := r.params[key]; !ok {
+		r.fail("缺少参数 %s", key)
+		return ""
+	}
+	return r.String(key, "")
+}
+
+func (r *paramReader) Int(key string, def int) int {
+	v, ok := r.params[key]
+	if !ok || v == nil {
+		return def
+	}
+	switch n := v.(type) {
+	case float64: // encoding/json 解码出的数字
+		if n != math.Trunc(n) {
+			r.fail("参数 %s 应为整数", key)
+			return def
+		}
+		return int(n)
+	case int:
+		return n
+	}
+	r.fail("参数 %s 应为整数", key)
+	return def
+}
+
+func (r *paramReader) Bool(key string, def bool) bool {
+	v, ok := r.params[key]
+	if !ok || v == nil {
+		return def
+	}
+	b, ok := v.(bool)
+	if !ok {
+		r.fail("参数 %s 应为布尔值", key)
+		return def
+	}
+	return b
+}
+
+func (r *paramReader) Enum(key, def string, allowed ...string) string {
+	s := r.String(key, def)
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	r.fail("参数 %s 的值 '%s' 无效，可选值: %s", key, s, strings.Join(allowed, ", "))
+	return def
+}
+
+func (r *paramReader) position(key string) int {
+	if r.Enum(key, "prefix", "prefix", "suffix") == "suffix" {
+		return -1
+	}
+	return 0
+}
+
+// pairs 读取映射列表：每项是新名称字符串，或 [原名称, 新名称] 数组
+func (r *paramReader) pairs(key string) []mappingPair {
+	v, ok := r.params[key]
+	if !ok || v == nil {
+		return nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		r.fail("参数 %s 应为数组", key)
+		return nil
+	}
+	pairs := make([]mappingPair, 0, len(list))
+	for i, item := range list {
+		switch e := item.(type) {
+		case string:
+			pairs = append(pairs, mappingPair{Value: e})
+			continue
+		case []any:
+			if len(e) == 2 {
+				k, ok1 := e[0].(string)
+				val, ok2 := e[1].(string)
+				if ok1 && ok2 {
+					pairs = append(pairs, mappingPair{Key: k, Value: val})
+					continue
+				}
+			}
+		}
+		r.fail("参数 %s 的第 %d 项应为字符串或 [原名称, 新名称]", key, i+1)
+		return nil
+	}
+	return pairs
+}
+
+func (r *paramReader) Err() error {
+	if len(r.problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(r.problems, "; "))
+}
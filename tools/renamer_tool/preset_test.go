@@ -0,0 +1,134 @@
+package renamer_tool
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateLegacyPreset(t *testing.T) {
+	tests := []struct {
+		name    string
+		defs    []RuleDefinition
+		want    []PresetRule
+		wantErr []string // 错误信息中应包含的内容
+	}{
+		{
+			name: "替换、插入和大小写",
+			defs: []RuleDefinition{
+				{Type: "replace", Param1: "a", Param2: "b", Scope: "ext"},
+				{Type: "insert", Param1: "x", Param2: "-1", Disabled: true},
+				{Type: "insert", Param1: "y", Param2: ""},
+				{Type: "case", Param1: "upper"},
+			},
+			want: []PresetRule{
+				{Type: "replace", Scope: "ext", Params: map[string]any{"old": "a", "new": "b"}},
+				{Type: "insert", Disabled: true, Params: map[string]any{"text": "x", "position": "suffix"}},
+				{Type: "insert", Params: map[string]any{"text": "y", "position": "prefix"}},
+				{Type: "case", Params: map[string]any{"case": "upper"}},
+			},
+		},
+		{
+			name: "编号的参数和附加选项",
+			defs: []RuleDefinition{
+				{Type: "serialize", Param1: "10", Param2: "2", Param3: "3", Param4: "-1", Flags: "perfolder,countdown,roman-lower,unknown"},
+				{Type: "serialize"},
+			},
+			want: []PresetRule{
+				{Type: "serialize", Params: map[string]any{
+					"start": 10, "step": 2, "padding": 3, "position": "suffix",
+					"per_folder": true, "natural": false, "count_down": true, "format": "roman-lower",
+				}},
+				{Type: "serialize", Params: map[string]any{
+					"start": 0, "step": 0, "padding": 0, "position": "prefix",
+					"per_folder": false, "natural": false, "count_down": false, "format": "",
+				}},
+			},
+		},
+		{
+			name: "模板和扩展名",
+			defs: []RuleDefinition{
+				{Type: "template", Param1: "{name}_{n}"},
+				{Type: "extension", Param1: "lower"},
+			},
+			want: []PresetRule{
+				{Type: "template", Params: map[string]any{"template": "{name}_{n}"}},
+				{Type: "extension", Params: map[string]any{"mode": "lower", "ext": ""}},
+			},
+		},
+		{name: "空预设", defs: nil, want: []PresetRule{}},
+		{
+			name:    "无法解析的数字",
+			defs:    []RuleDefinition{{Type: "insert", Param1: "x", Param2: "end"}, {Type: "serialize", Param1: "1", Param2: "two"}},
+			wantErr: []string{"第 1 条规则的 位置 不是数字: 'end'", "第 2 条规则的 步长 不是数字: 'two'"},
+		},
+		{
+			name:    "未知的规则类型",
+			defs:    []RuleDefinition{{Type: "replace", Param1: "a"}, {Type: "regex", Param1: ".*"}},
+			wantErr: []string{"第 2 条规则的类型未知: 'regex'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := migrateLegacyPreset(tt.defs)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("migrateLegacyPreset() = %+v, 期望出错", got)
+				}
+				for _, s := range tt.wantErr {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("错误 %q 中没有 %q", err, s)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrateLegacyPreset() 出错: %v", err)
+			}
+			if got.Version != presetVersion {
+				t.Errorf("Version = %d, 期望 %d", got.Version, presetVersion)
+			}
+			if !reflect.DeepEqual(got.Rules, tt.want) {
+				t.Errorf("Rules = %+v\n期望 %+v", got.Rules, tt.want)
+			}
+			// 迁移结果必须能创建规则
+			if _, err := got.BuildRules(); err != nil {
+				t.Errorf("BuildRules() 出错: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantMigrated bool
+		wantRules    int
+		wantErr      bool
+	}{
+		{name: "旧版数组", data: ` [{"Type":"replace","Param1":"a","Param2":"b"}]`, wantMigrated: true, wantRules: 1},
+		{name: "旧版数组中的错误", data: `[{"Type":"serialize","Param1":"x"}]`, wantErr: true},
+		{name: "当前版本", data: `{"version":2,"rules":[{"type":"case","params":{"case":"upper"}}]}`, wantRules: 1},
+		{name: "缺少版本号", data: `{"rules":[]}`, wantErr: true},
+		{name: "版本过高", data: `{"version":3,"rules":[]}`, wantErr: true},
+		{name: "无效的 JSON", data: `{"version":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, migrated, err := parsePreset([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePreset() = %+v, 期望出错", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePreset() 出错: %v", err)
+			}
+			if migrated != tt.wantMigrated || len(p.Rules) != tt.wantRules {
+				t.Errorf("parsePreset() = %d 条规则, migrated=%v", len(p.Rules), migrated)
+			}
+		})
+	}
+}
@@ -2,19 +2,20 @@
 package renamer_tool
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image/color"
-	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
-	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"yanshu-toolkit/core"
 
 	"fyne.io/fyne/v2"
@@ -27,26 +28,89 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-// --- 数据结构和规则实现 (无变化) ---
+// --- 数据结构和规则实现 ---
+// Rule 的 Apply 接收当前名称和对应的 FileItem，规则可以借此读取文件的元数据。
 type Rule interface {
-	Apply(string, int) string
+	Apply(string, *FileItem, int) string
 	Describe() string
+	options() *ruleBase
 }
-type RuleDefinition struct{ Type, Param1, Param2, Param3, Param4 string }
-type FileItem struct{ OriginalPath, OriginalName, NewName, Status string }
-type ReplaceRule struct{ Old, New string }
 
-func (r *ReplaceRule) Apply(original string, index int) string {
+// RuleScope 决定规则作用于文件名的哪一部分
+type RuleScope int
+
+const (
+	ScopeName RuleScope = iota // 仅名称 (不含扩展名)
+	ScopeExt                   // 仅扩展名 (不含点)
+	ScopeFull                  // 完整文件名
+)
+
+var scopeLabels = []string{"仅名称", "仅扩展名", "完整名称"}
+var scopeKeys = []string{"name", "ext", "full"}
+
+// ruleBase 嵌入到每个规则中，保存所有规则共有的选项
+type ruleBase struct {
+	Scope    RuleScope
+	Disabled bool // 停用的规则保留在列表中，但计算预览时跳过
+}
+
+func (b *ruleBase) options() *ruleBase { return b }
+
+// describeRule 在规则描述前标注非默认的作用范围
+func describeRule(r Rule) string {
+	if scope := r.options().Scope; scope != ScopeName {
+		return fmt.Sprintf("[%s] %s", scopeLabels[scope], r.Describe())
+	}
+	return r.Describe()
+}
+
+type FileItem struct {
+	OriginalPath, OriginalName, NewName, Status string
+	ErrorMessage                                string // 重命名失败时的错误信息
+	PreviousPath                                string // 成功重命名 (移动) 前的路径，用于导出报告
+	Size                                        int64
+	ModTime                                     time.Time
+	Excluded                                    bool // 取消勾选的文件不参与重命名和编号
+	IsDir                                       bool
+	meta                                        metaCache
+}
+
+// 决定预览中显示 (并参与重命名) 的条目类型
+const (
+	modeFilesOnly = iota
+	modeFoldersOnly
+	modeFilesAndFolders
+)
+
+var itemModeLabels = []string{"仅文件", "仅文件夹", "文件和文件夹"}
+
+func itemModeAccepts(mode int, isDir bool) bool {
+	switch mode {
+	case modeFoldersOnly:
+		return isDir
+	case modeFilesAndFolders:
+		return true
+	}
+	return !isDir
+}
+
+type ReplaceRule struct {
+	ruleBase
+	Old, New string
+}
+
+func (r *ReplaceRule) Apply(original string, item *FileItem, index int) string {
 	return strings.ReplaceAll(original, r.Old, r.New)
 }
 func (r *ReplaceRule) Describe() string { return fmt.Sprintf("替换: '%s' -> '%s'", r.Old, r.New) }
 
 type InsertRule struct {
+	ruleBase
 	Text     string
 	Position int
 }
 
-func (r *InsertRule) Apply(original string, index int) string {
+func (r *InsertRule) Apply(original string, item *FileItem, index int) string {
 	if r.Position == -1 {
 		return original + r.Text
 	}
@@ -60,37 +124,67 @@ func (r *InsertRule) Describe() string {
 	return fmt.Sprintf("在%s插入: '%s'", posStr, r.Text)
 }
 
-type CaseRule struct{ CaseType string }
+// SerializeRule 添加序号。默认按文件在预览中的顺序编号，
+// 也可以每个文件夹重新计数、按文件名自然顺序编号或倒序编号。
+type SerializeRule struct {
+	ruleBase
+	Start, Step, Padding, Position int
+	PerFolder                      bool   // 每个文件夹重新从起始数字计数
+	Natural                        bool   // 按文件名自然顺序 ("2" 在 "10" 之前) 编号，而不是预览顺序
+	CountDown                      bool   // 倒序编号，最后一个文件得到起始数字
+	Format                         string // "" 数字, "lower"/"upper" 字母, "roman"/"roman-lower" 罗马数字
 
-func (r *CaseRule) Apply(original string, index int) string {
-	switch r.CaseType {
-	case "upper":
-		return strings.ToUpper(original)
-	case "lower":
-		return strings.ToLower(original)
-	case "title":
-		return strings.Title(strings.ToLower(original))
-	}
-	return original
+	// Prepare 计算出的每个文件的序号位置，nil 时直接使用传入的 index
+	positions map[*FileItem]int
 }
-func (r *CaseRule) Describe() string {
-	desc := ""
-	switch r.CaseType {
-	case "upper":
-		desc = "全部大写"
-	case "lower":
-		desc = "全部小写"
-	case "title":
-		desc = "首字母大写"
-	}
-	return fmt.Sprintf("大小写: %s", desc)
+
+var serializeFormats = []string{"", "lower", "upper", "roman", "roman-lower"}
+var serializeFormatLabels = []string{"数字", "小写字母", "大写字母", "罗马数字", "小写罗马数字"}
+
+// rulePreparer 由需要预先了解全部待重命名文件的规则实现，
+// 在逐个计算新名称之前以预览顺序传入所有参与重命名的文件。
+type rulePreparer interface {
+	Prepare(items []*FileItem)
 }
 
-type SerializeRule struct{ Start, Step, Padding, Position int }
+func (r *SerializeRule) Prepare(items []*FileItem) {
+	if !r.PerFolder && !r.Natural && !r.CountDown {
+		r.positions = nil
+		return
+	}
+	groups := make(map[string][]*FileItem)
+	var groupOrder []string
+	for _, item := range items {
+		key := ""
+		if r.PerFolder {
+			key = filepath.Dir(item.OriginalPath)
+		}
+		if _, ok := groups[key]; !ok {
+			groupOrder = append(groupOrder, key)
+		}
+		groups[key] = append(groups[key], item)
+	}
+	r.positions = make(map[*FileItem]int, len(items))
+	for _, key := range groupOrder {
+		group := groups[key]
+		if r.Natural {
+			sort.SliceStable(group, func(i, j int) bool { return naturalLess(group[i].OriginalName, group[j].OriginalName) })
+		}
+		for i, item := range group {
+			if r.CountDown {
+				r.positions[item] = len(group) - 1 - i
+			} else {
+				r.positions[item] = i
+			}
+		}
+	}
+}
 
-func (r *SerializeRule) Apply(original string, index int) string {
-	num := r.Start + (index * r.Step)
-	numStr := fmt.Sprintf(fmt.Sprintf("%%0%dd", r.Padding), num)
+func (r *SerializeRule) Apply(original string, item *FileItem, index int) string {
+	if pos, ok := r.positions[item]; ok {
+		index = pos
+	}
+	numStr := formatSerial(r.Start+(index*r.Step), r.Padding, r.Format)
 	if r.Position == -1 {
 		return original + numStr
 	}
@@ -101,7 +195,273 @@ func (r *SerializeRule) Describe() string {
 	if r.Position == -1 {
 		posStr = "末尾"
 	}
-	return fmt.Sprintf("在%s添加序列: 从%d开始, 步长%d, 补%d位", posStr, r.Start, r.Step, r.Padding)
+	desc := fmt.Sprintf("在%s添加序列: 从%d开始, 步长%d, 补%d位", posStr, r.Start, r.Step, r.Padding)
+	for i, f := range serializeFormats {
+		if f != "" && f == r.Format {
+			desc += ", " + serializeFormatLabels[i]
+		}
+	}
+	if r.PerFolder {
+		desc += ", 按文件夹计数"
+	}
+	if r.Natural {
+		desc += ", 自然顺序"
+	}
+	if r.CountDown {
+		desc += ", 倒序"
+	}
+	return desc
+}
+
+// formatSerial 按格式输出序号。字母和罗马数字无法表示的数字 (如 0 或负数) 退回为普通数字。
+func formatSerial(num, padding int, format string) string {
+	switch format {
+	case "lower", "upper":
+		if num > 0 {
+			letters := toLetters(num)
+			if format == "lower" {
+				letters = strings.ToLower(letters)
+			}
+			return letters
+		}
+	case "roman", "roman-lower":
+		if num > 0 && num < 4000 {
+			roman := toRoman(num)
+			if format == "roman-lower" {
+				roman = strings.ToLower(roman)
+			}
+			return roman
+		}
+	}
+	return fmt.Sprintf("%0*d", padding, num)
+}
+
+// toLetters 把 1, 2, ..., 26, 27 转换为 A, B, ..., Z, AA
+func toLetters(n int) string {
+	var b []byte
+	for n > 0 {
+		n--
+		b = append([]byte{byte('A' + n%26)}, b...)
+		n /= 26
+	}
+	return string(b)
+}
+
+func toRoman(n int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var sb strings.Builder
+	for i, v := range values {
+		for n >= v {
+			sb.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return sb.String()
+}
+
+// computeNewNames 为参与重命名的文件 (按预览顺序) 计算新名称
+func computeNewNames(rules []Rule, items []*FileItem, multiExts []string) {
+	names, _ := newNames(context.Background(), rules, items, multiExts, nil)
+	for i, item := range items {
+		item.NewName = names[i]
+	}
+}
+
+// newNames 计算新名称但不修改条目，可以在后台执行。
+// rules 会被 Prepare 修改，因此后台计算时应传入规则的副本。ctx 取消时返回 ctx.Err()。
+func newNames(ctx context.Context, rules []Rule, items []*FileItem, multiExts []string, progress func(done, total int)) ([]string, error) {
+	for _, rule := range rules {
+		if p, ok := rule.(rulePreparer); ok && !rule.options().Disabled {
+			p.Prepare(items)
+		}
+	}
+	names := make([]string, len(items))
+	for i, item := range items {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		names[i] = applyRules(rules, item, i, multiExts)
+		if progress != nil {
+			progress(i+1, len(items))
+		}
+	}
+	return names, nil
+}
+
+// TemplateRule 用 {token} 拼出新名称，例如 "{exif:DateTimeOriginal:2006-01-02}_{index:03}"。
+// 未知的 token 原样保留，取不到值的 token 替换为空字符串。
+type TemplateRule struct {
+	ruleBase
+	Template string
+}
+
+var templateTokenRe = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// 文件名中不允许出现的字符，元数据里的值 (如 "AC/DC") 需要替换掉
+var invalidNameChars = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+func (r *TemplateRule) Apply(original string, item *FileItem, index int) string {
+	return templateTokenRe.ReplaceAllStringFunc(r.Template, func(token string) string {
+		value, ok := resolveTemplateToken(token[1:len(token)-1], original, item, index)
+		if !ok {
+			return token
+		}
+		return invalidNameChars.Replace(value)
+	})
+}
+func (r *TemplateRule) Describe() string { return fmt.Sprintf("模板: '%s'", r.Template) }
+
+func resolveTemplateToken(token, original string, item *FileItem, index int) (string, bool) {
+	kind, arg, _ := strings.Cut(token, ":")
+	switch kind {
+	case "name":
+		return original, true
+	case "parent":
+		return filepath.Base(filepath.Dir(item.OriginalPath)), true
+	case "index":
+		width, _ := strconv.Atoi(arg)
+		return fmt.Sprintf("%0*d", width, index+1), true
+	case "mtime":
+		if arg == "" {
+			arg = "2006-01-02"
+		}
+		if item.ModTime.IsZero() {
+			return "", true
+		}
+		return item.ModTime.Format(arg), true
+	case "width", "height":
+		meta := item.Metadata()
+		size := meta.Width
+		if kind == "height" {
+			size = meta.Height
+		}
+		if size == 0 {
+			return "", true
+		}
+		return strconv.Itoa(size), true
+	case "exif":
+		tag, layout, _ := strings.Cut(arg, ":")
+		value := item.Metadata().Exif[tag]
+		if layout != "" && value != "" {
+			if t, ok := parseExifTime(value); ok {
+				return t.Format(layout), true
+			}
+		}
+		return value, true
+	case "id3":
+		return item.Metadata().Tags[strings.ToLower(arg)], true
+	}
+	return "", false
+}
+
+// ExtensionRule 修改扩展名或转换其大小写，总是作用于扩展名。
+type ExtensionRule struct {
+	ruleBase
+	Mode   string // "set", "lower", "upper"
+	NewExt string
+}
+
+func (r *ExtensionRule) Apply(original string, item *FileItem, index int) string {
+	switch r.Mode {
+	case "set":
+		return strings.TrimPrefix(r.NewExt, ".")
+	case "lower":
+		return strings.ToLower(original)
+	case "upper":
+		return strings.ToUpper(original)
+	}
+	return original
+}
+func (r *ExtensionRule) Describe() string {
+	switch r.Mode {
+	case "set":
+		if r.NewExt == "" {
+			return "移除扩展名"
+		}
+		return fmt.Sprintf("修改扩展名为: '.%s'", strings.TrimPrefix(r.NewExt, "."))
+	case "lower":
+		return "扩展名转为小写"
+	case "upper":
+		return "扩展名转为大写"
+	}
+	return "扩展名"
+}
+
+// defaultMultiExts 是默认视为一个整体的多段扩展名
+var defaultMultiExts = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst"}
+
+// splitExt 把文件名拆成名称和扩展名 (含点)，multiExts 中的多段扩展名作为一个整体。
+// 以点开头且没有其他点的名称 (如 ".bashrc") 视为没有扩展名。
+func splitExt(name string, multiExts []string) (string, string) {
+	lower := strings.ToLower(name)
+	for _, ext := range multiExts {
+		if len(name) > len(ext) && strings.HasSuffix(lower, strings.ToLower(ext)) {
+			return name[:len(name)-len(ext)], name[len(name)-len(ext):]
+		}
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// parseMultiExts 解析逗号分隔的多段扩展名设置，例如 "tar.gz, .tar.bz2"
+func parseMultiExts(text string) []string {
+	var exts []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+		exts = append(exts, "."+strings.TrimPrefix(part, "."))
+	}
+	// 更长的扩展名优先匹配
+	sort.SliceStable(exts, func(i, j int) bool { return len(exts[i]) > len(exts[j]) })
+	return exts
+}
+
+// applyRules 依次对一个文件应用所有规则，每条规则只改动它作用范围内的部分。
+// 文件夹没有扩展名，整个名称都视为 "名称" 部分。
+// 规则输出中的文件夹部分 (如目标文件夹规则添加的 "2024/05/") 会被单独累积，
+// 后续规则只处理文件名部分。
+func applyRules(rules []Rule, item *FileItem, index int, multiExts []string) string {
+	split := func(name string) (string, string) {
+		if item.IsDir {
+			return name, ""
+		}
+		return splitExt(name, multiExts)
+	}
+	newName := item.OriginalName
+	dir := ""
+	for _, rule := range rules {
+		if rule.options().Disabled {
+			continue
+		}
+		switch rule.options().Scope {
+		case ScopeFull:
+			newName = rule.Apply(newName, item, index)
+		case ScopeExt:
+			baseName, ext := split(newName)
+			ext = rule.Apply(strings.TrimPrefix(ext, "."), item, index)
+			if ext != "" {
+				ext = "." + ext
+			}
+			newName = baseName + ext
+		default:
+			baseName, ext := split(newName)
+			newName = rule.Apply(baseName, item, index) + ext
+		}
+		if d, base := splitTargetDir(newName); d != "" {
+			if filepath.IsAbs(d) {
+				dir = d
+			} else {
+				dir = filepath.Join(dir, d)
+			}
+			newName = base
+		}
+	}
+	if dir != "" && newName != "" {
+		return filepath.Join(dir, newName)
+	}
+	return newName
 }
 
 // --- renamerTool 主结构 ---
@@ -118,15 +478,44 @@ type renamerTool struct {
 	mainView fyne.CanvasObject
 
 	// 核心变更: 放弃数据绑定，使用普通切片。移除所有锁。
-	fileItems []*FileItem
-	rules     []Rule
+	fileItems    []*FileItem // 按添加顺序保存的全部文件
+	visibleItems []*FileItem // 经过过滤和排序后显示在预览中的文件
+	rules        []Rule
+
+	// 预览列表的过滤和排序状态
+	filterText  string
+	filterRegex bool
+	sortKey     string
+	sortDesc    bool
+	itemMode    int
+	copyMode    bool // 复制到新名称，保留原文件
 
 	// UI 组件引用
 	ruleList          *widget.List
 	previewList       *widget.List
 	presetSelect      *widget.Select
-	presets           map[string][]RuleDefinition
+	presets           map[string]Preset
 	selectedRuleIndex widget.ListItemID
+	multiExts         []string
+
+	// 后台任务状态，只在 UI 线程中读写 (见 jobs.go)
+	jobCancel      context.CancelFunc // 正在进行的扫描或重命名任务
+	jobText        string
+	renaming       bool
+	previewTimer   *time.Timer
+	previewCancel  context.CancelFunc
+	previewGen     int
+	previewText    string
+	previewPending bool // 防抖计时中，还没有开始计算
+	renameQueued   bool // 点击重命名时预览还没有更新，等预览完成后再执行
+	changedCount   int
+	jobButtons     []fyne.Disableable
+	statusLabel    *widget.Label
+	progressBar    *widget.ProgressBar
+	scanProgress   *widget.ProgressBarInfinite
+	cancelBtn      *widget.Button
+	mappingBtn     *widget.Button
+	mappingReport  mappingReport // 最近一次预览中名称映射规则的问题
 }
 
 func (t *renamerTool) Title() string       { return "批量重命名" }
@@ -134,20 +523,22 @@ func (t *renamerTool) Icon() fyne.Resource { return theme.DocumentCreateIcon() }
 func (t *renamerTool) Category() string    { return "文件管理" }
 
 func (t *renamerTool) Destroy() {
+	t.cancelJobs()
 }
 
 // --- UI 构建 ---
+// OnDropped 把拖入的文件和文件夹加入列表
+func (t *renamerTool) OnDropped(_ fyne.Position, uris []fyne.URI) {
+	t.addFilesFromURIs(uris)
+}
+
 func (t *renamerTool) View(win fyne.Window) fyne.CanvasObject {
 	t.win = win
 	t.fileItems = []*FileItem{}
+	t.visibleItems = []*FileItem{}
 	t.rules = []Rule{}
 	t.selectedRuleIndex = -1
-
-	t.win.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
-		if t.mainView != nil && t.mainView.Visible() {
-			t.addFilesFromURIs(uris)
-		}
-	})
+	t.multiExts = defaultMultiExts
 
 	leftPanel := t.createLeftPanel()
 	rightPanel := t.createRightPanel()
@@ -165,10 +556,17 @@ func (t *renamerTool) createLeftPanel() fyne.CanvasObject {
 		func() int {
 			return len(t.rules)
 		},
-		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func() fyne.CanvasObject { return newRuleRow() },
 		func(i widget.ListItemID, o fyne.CanvasObject) {
 			if i < len(t.rules) {
-				o.(*widget.Label).SetText(t.rules[i].Describe())
+				rule := t.rules[i]
+				row := o.(*ruleRow)
+				row.Set(describeRule(rule), !rule.options().Disabled, func(enabled bool) {
+					rule.options().Disabled = !enabled
+					t.updatePreviews()
+				})
+				row.onTapped = func() { t.ruleList.Select(i) }
+				row.onDoubleTapped = func() { t.showAddRuleDialog(i) }
 			}
 		},
 	)
@@ -178,85 +576,218 @@ func (t *renamerTool) createLeftPanel() fyne.CanvasObject {
 			t.selectedRuleIndex = -1
 		}
 	}
-	addRuleBtn := widget.NewButtonWithIcon("添加规则", theme.ContentAddIcon(), func() { t.showAddRuleDialog() })
+	addRuleBtn := widget.NewButtonWithIcon("添加规则", theme.ContentAddIcon(), func() { t.showAddRuleDialog(-1) })
 	removeRuleBtn := widget.NewButtonWithIcon("移除选中", theme.ContentRemoveIcon(), func() { t.removeSelectedRule() })
+	editRuleBtn := widget.NewButtonWithIcon("编辑", theme.DocumentCreateIcon(), func() {
+		if t.selectedRuleIndex >= 0 {
+			t.showAddRuleDialog(t.selectedRuleIndex)
+		}
+	})
+	duplicateRuleBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() { t.duplicateSelectedRule() })
+	moveUpBtn := widget.NewButtonWithIcon("上移", theme.MoveUpIcon(), func() { t.moveSelectedRule(-1) })
+	moveDownBtn := widget.NewButtonWithIcon("下移", theme.MoveDownIcon(), func() { t.moveSelectedRule(1) })
+	ruleButtons := container.NewVBox(
+		container.NewGridWithColumns(2, addRuleBtn, removeRuleBtn),
+		container.NewGridWithColumns(4, editRuleBtn, duplicateRuleBtn, moveUpBtn, moveDownBtn),
+	)
 	t.presetSelect = widget.NewSelect([]string{}, func(name string) { t.loadPreset(name) })
 	t.presetSelect.PlaceHolder = "加载预设..."
 	t.loadPresets()
 	savePresetBtn := widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), func() { t.showSavePresetDialog() })
 	deletePresetBtn := widget.NewButtonWithIcon("删除", theme.DeleteIcon(), t.deleteCurrentPreset)
+	importPresetBtn := widget.NewButtonWithIcon("导入", theme.FolderOpenIcon(), func() { t.showImportPresetDialog() })
+	exportPresetBtn := widget.NewButtonWithIcon("导出", theme.UploadIcon(), func() { t.showExportPresetDialog() })
 
 	return container.NewBorder(
 		container.NewVBox(widget.NewLabelWithStyle("重命名规则", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}), widget.NewSeparator()),
-		container.NewVBox(widget.NewSeparator(), widget.NewLabelWithStyle("预设", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}), t.presetSelect, container.NewGridWithColumns(2, savePresetBtn, deletePresetBtn)),
+		container.NewVBox(widget.NewSeparator(), widget.NewLabelWithStyle("预设", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}), t.presetSelect, container.NewGridWithColumns(4, savePresetBtn, deletePresetBtn, importPresetBtn, exportPresetBtn)),
 		nil, nil,
-		container.NewBorder(nil, container.NewGridWithColumns(2, addRuleBtn, removeRuleBtn), nil, nil, t.ruleList),
+		container.NewBorder(nil, ruleButtons, nil, nil, t.ruleList),
 	)
 }
 
 func (t *renamerTool) createRightPanel() fyne.CanvasObject {
 	t.previewList = widget.NewList(
 		func() int {
-			return len(t.fileItems)
+			return len(t.visibleItems)
 		},
 		func() fyne.CanvasObject {
 			return newColoredLabel()
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i < len(t.fileItems) {
-				item := t.fileItems[i]
-				o.(*coloredLabel).SetText(item.OriginalName, item.NewName, item.Status)
+			if i < len(t.visibleItems) {
+				item := t.visibleItems[i]
+				label := o.(*coloredLabel)
+				label.SetText(item.OriginalName, item.NewName, item.Status)
+				label.SetIsDir(item.IsDir)
+				size := core.FormatSize(item.Size)
+				if item.IsDir {
+					size = "文件夹"
+				}
+				detail := size + "  " + item.ModTime.Format("2006-01-02 15:04")
+				if item.ErrorMessage != "" {
+					detail = item.ErrorMessage
+				}
+				label.SetDetail(detail)
+				label.SetIncluded(!item.Excluded, func(checked bool) {
+					item.Excluded = !checked
+					t.updatePreviews()
+				})
 			}
 		},
 	)
-	return container.NewBorder(widget.NewLabelWithStyle("预览 (可拖放文件到此窗口)", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}), nil, nil, nil, t.previewList)
+	multiExtEntry := widget.NewEntry()
+	multiExtEntry.SetText(strings.Join(t.multiExts, ", "))
+	multiExtEntry.OnChanged = func(text string) {
+		t.multiExts = parseMultiExts(text)
+		t.updatePreviews()
+	}
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("过滤文件名，例如 *.jpg")
+	filterEntry.Validator = func(text string) error {
+		_, err := newNameMatcher(text, t.filterRegex)
+		return err
+	}
+	filterEntry.OnChanged = func(text string) {
+		t.filterText = text
+		t.updatePreviews()
+	}
+	filterMode := widget.NewSelect([]string{"通配符", "正则"}, func(mode string) {
+		t.filterRegex = mode == "正则"
+		t.updatePreviews()
+	})
+	filterMode.SetSelected("通配符")
+	selectAllBtn := widget.NewButton("全选", func() { t.setVisibleIncluded(true) })
+	selectNoneBtn := widget.NewButton("全不选", func() { t.setVisibleIncluded(false) })
+	filterRow := container.NewBorder(nil, nil, filterMode, container.NewHBox(selectAllBtn, selectNoneBtn), filterEntry)
+
+	header := container.NewVBox(
+		widget.NewLabelWithStyle("预览 (可拖放文件到此窗口，仅重命名显示且勾选的文件)", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewForm(widget.NewFormItem("多段扩展名:", multiExtEntry)),
+		filterRow,
+		t.createSortHeader(),
+	)
+	return container.NewBorder(header, nil, nil, nil, t.previewList)
+}
+
+// createSortHeader 创建可点击的列标题，再次点击同一列切换升序/降序
+func (t *renamerTool) createSortHeader() fyne.CanvasObject {
+	columns := []struct{ label, key string }{
+		{"添加顺序", sortNone}, {"名称", sortName}, {"自然顺序", sortNatural},
+		{"大小", sortSize}, {"修改时间", sortModTime}, {"路径", sortPath},
+	}
+	buttons := make([]*widget.Button, len(columns))
+	updateIcons := func() {
+		for i, col := range columns {
+			var icon fyne.Resource
+			if col.key == t.sortKey {
+				icon = theme.MoveUpIcon()
+				if t.sortDesc {
+					icon = theme.MoveDownIcon()
+				}
+			}
+			buttons[i].SetIcon(icon)
+		}
+	}
+	header := container.NewGridWithColumns(len(columns))
+	for i, col := range columns {
+		buttons[i] = widget.NewButton(col.label, func() {
+			if t.sortKey == col.key {
+				t.sortDesc = !t.sortDesc
+			} else {
+				t.sortKey, t.sortDesc = col.key, false
+			}
+			updateIcons()
+			t.updatePreviews()
+		})
+		buttons[i].Importance = widget.LowImportance
+		header.Add(buttons[i])
+	}
+	updateIcons()
+	return header
+}
+
+func (t *renamerTool) setVisibleIncluded(included bool) {
+	for _, item := range t.visibleItems {
+		item.Excluded = !included
+	}
+	t.updatePreviews()
 }
 
 func (t *renamerTool) createBottomPanel() fyne.CanvasObject {
 	addFilesBtn := widget.NewButton("选择文件...", func() { t.showSelectFilesDialog() })
 	addFolderBtn := widget.NewButton("添加文件夹(递归)", func() { t.showAddFolderRecursiveDialog() })
+	modeSelect := widget.NewSelect(itemModeLabels, nil)
+	modeSelect.SetSelectedIndex(t.itemMode)
+	modeSelect.OnChanged = func(string) {
+		t.itemMode = modeSelect.SelectedIndex()
+		t.updatePreviews()
+	}
 
 	clearBtn := widget.NewButton("清空列表", func() {
-		t.fileItems = []*FileItem{}
+		t.renameQueued = false
+		t.cancelPreview()
+		t.fileItems = nil
+		t.visibleItems = nil
+		t.changedCount = 0
+		t.mappingReport = mappingReport{}
 		t.previewList.Refresh()
-		// 手动触发GC并建议将内存返回给操作系统
-		runtime.GC()
-		debug.FreeOSMemory()
+		t.refreshStatus()
 	})
 
 	renameBtn := widget.NewButtonWithIcon("开始重命名", theme.ConfirmIcon(), func() { t.executeRename() })
 	renameBtn.Importance = widget.HighImportance
-	return container.NewVBox(container.NewGridWithColumns(4, addFilesBtn, addFolderBtn, clearBtn, renameBtn), widget.NewSeparator())
+	// 扫描或重命名期间禁用这些按钮
+	t.jobButtons = []fyne.Disableable{addFilesBtn, addFolderBtn, clearBtn, renameBtn}
+
+	t.statusLabel = widget.NewLabel("")
+	t.statusLabel.Truncation = fyne.TextTruncateEllipsis
+	t.progressBar = widget.NewProgressBar()
+	t.progressBar.Hide()
+	t.scanProgress = widget.NewProgressBarInfinite()
+	t.scanProgress.Stop()
+	t.scanProgress.Hide()
+	t.cancelBtn = widget.NewButtonWithIcon("取消", theme.CancelIcon(), t.cancelJobs)
+	t.cancelBtn.Hide()
+	t.mappingBtn = widget.NewButtonWithIcon("映射问题", theme.WarningIcon(), t.showMappingReport)
+	t.mappingBtn.Importance = widget.WarningImportance
+	t.mappingBtn.Hide()
+	exportBtn := widget.NewButtonWithIcon("导出报告", theme.DocumentSaveIcon(), t.showExportReportDialog)
+	progress := container.NewGridWrap(fyne.NewSize(200, t.progressBar.MinSize().Height), container.NewStack(t.progressBar, t.scanProgress))
+	statusRow := container.NewBorder(nil, nil, nil, container.NewHBox(t.mappingBtn, progress, t.cancelBtn, exportBtn), t.statusLabel)
+	t.refreshStatus()
+
+	copyCheck := widget.NewCheck("复制而不是移动", func(checked bool) { t.copyMode = checked })
+
+	return container.NewVBox(container.NewGridWithColumns(6, modeSelect, addFilesBtn, addFolderBtn, clearBtn, copyCheck, renameBtn), statusRow, widget.NewSeparator())
+}
+
+// --- 文件处理方法 ---
+// 添加文件夹时会同时收集其中的子文件夹 (不含文件夹本身)，是否显示由 itemMode 决定。
+func newFileItem(path string) *FileItem {
+	item := &FileItem{OriginalPath: path, OriginalName: filepath.Base(path), NewName: filepath.Base(path)}
+	if info, err := os.Stat(path); err == nil {
+		item.Size = info.Size()
+		item.ModTime = info.ModTime()
+		item.IsDir = info.IsDir()
+		if item.IsDir {
+			item.Size = 0
+		}
+	}
+	return item
 }
 
-// --- 文件处理方法 (回归原始同步逻辑) ---
 func (t *renamerTool) addFile(path string) {
-	t.fileItems = append(t.fileItems, &FileItem{OriginalPath: path, OriginalName: filepath.Base(path), NewName: filepath.Base(path)})
+	t.fileItems = append(t.fileItems, newFileItem(path))
 }
 
 func (t *renamerTool) addFilesFromURIs(uris []fyne.URI) {
-	var pathsToAdd []string
+	var roots []string
 	for _, u := range uris {
-		path := u.Path()
-		info, err := os.Stat(path)
-		if err != nil {
-			continue
-		}
-		if info.IsDir() {
-			filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
-				if err == nil && !d.IsDir() {
-					pathsToAdd = append(pathsToAdd, p)
-				}
-				return nil
-			})
-		} else {
-			pathsToAdd = append(pathsToAdd, path)
-		}
-	}
-	for _, p := range pathsToAdd {
-		t.addFile(p)
+		roots = append(roots, u.Path())
 	}
-	t.updatePreviews()
+	t.startScan(roots)
 }
 
 func (t *renamerTool) showSelectFilesDialog() {
@@ -273,9 +804,13 @@ func (t *renamerTool) showSelectFilesDialog() {
 		var fileNames []string
 		var fileMap = make(map[string]string)
 		for _, file := range files {
-			if !file.IsDir() {
-				fileNames = append(fileNames, file.Name())
-				fileMap[file.Name()] = filepath.Join(dirPath, file.Name())
+			if t.itemModeAccepts(file.IsDir()) {
+				name := file.Name()
+				if file.IsDir() {
+					name += string(filepath.Separator)
+				}
+				fileNames = append(fileNames, name)
+				fileMap[name] = filepath.Join(dirPath, file.Name())
 			}
 		}
 		if len(fileNames) == 0 {
@@ -295,13 +830,13 @@ func (t *renamerTool) showSelectFilesDialog() {
 			if !ok {
 				return
 			}
-			var uris []fyne.URI
+			// 这里选中的文件夹只添加其本身，不展开其中的内容
 			for _, name := range checkGroup.Selected {
 				if fullPath, exists := fileMap[name]; exists {
-					uris = append(uris, storage.NewFileURI(fullPath))
+					t.addFile(fullPath)
 				}
 			}
-			t.addFilesFromURIs(uris)
+			t.updatePreviews()
 		}, t.win)
 		d.Resize(fyne.NewSize(400, 500))
 		d.Show()
@@ -317,21 +852,8 @@ func (t *renamerTool) showAddFolderRecursiveDialog() {
 }
 
 // --- 规则处理方法 ---
-func (t *renamerTool) updatePreviews() {
-	if len(t.fileItems) > 0 {
-		for i, item := range t.fileItems {
-			newName := item.OriginalName
-			for _, rule := range t.rules {
-				ext := filepath.Ext(newName)
-				baseName := strings.TrimSuffix(newName, ext)
-				baseName = rule.Apply(baseName, i)
-				newName = baseName + ext
-			}
-			item.NewName = newName
-			item.Status = ""
-		}
-	}
-	t.previewList.Refresh()
+func (t *renamerTool) itemModeAccepts(isDir bool) bool {
+	return itemModeAccepts(t.itemMode, isDir)
 }
 
 func (t *renamerTool) addRule(rule Rule) {
@@ -351,99 +873,350 @@ func (t *renamerTool) removeSelectedRule() {
 	t.updatePreviews()
 }
 
+func (t *renamerTool) duplicateSelectedRule() {
+	idx := t.selectedRuleIndex
+	if idx < 0 || idx >= len(t.rules) {
+		return
+	}
+	clone, err := cloneRule(t.rules[idx])
+	if err != nil {
+		dialog.ShowError(err, t.win)
+		return
+	}
+	t.rules = append(t.rules[:idx+1], append([]Rule{clone}, t.rules[idx+1:]...)...)
+	t.ruleList.Refresh()
+	t.ruleList.Select(idx + 1)
+	t.updatePreviews()
+}
+
+// moveSelectedRule 把选中的规则向上 (delta=-1) 或向下 (delta=1) 移动一位
+func (t *renamerTool) moveSelectedRule(delta int) {
+	idx := t.selectedRuleIndex
+	target := idx + delta
+	if idx < 0 || target < 0 || target >= len(t.rules) {
+		return
+	}
+	t.rules[idx], t.rules[target] = t.rules[target], t.rules[idx]
+	t.ruleList.Refresh()
+	t.ruleList.Select(target)
+	t.updatePreviews()
+}
+
 func (t *renamerTool) executeRename() {
 	if len(t.fileItems) == 0 {
 		dialog.ShowInformation("提示", "文件列表为空。", t.win)
 		return
 	}
-	renamedCount, errorCount := 0, 0
-	for i, item := range t.fileItems {
-		if item.OriginalName == item.NewName {
+	// 规则或过滤条件刚修改过时，列表中的新名称还是旧的预览，先按当前设置重新计算
+	if t.previewPending || t.previewCancel != nil {
+		if t.previewTimer != nil {
+			t.previewTimer.Stop()
+		}
+		t.startPreview()
+		if t.previewCancel != nil {
+			t.renameQueued = true
+		}
+		return
+	}
+	var pending []*FileItem
+	for _, item := range t.visibleItems {
+		if !item.Excluded && item.OriginalName != item.NewName {
+			pending = append(pending, item)
+		}
+	}
+	if conflicts := findConflicts(pending, t.copyMode); len(conflicts) > 0 {
+		entry := widget.NewMultiLineEntry()
+		entry.SetText(strings.Join(conflicts, "\n"))
+		entry.Wrapping = fyne.TextWrapWord
+		d := dialog.NewCustom(fmt.Sprintf("发现 %d 个冲突，未执行重命名", len(conflicts)), "关闭", container.NewScroll(entry), t.win)
+		d.Resize(fyne.NewSize(600, 400))
+		d.Show()
+		return
+	}
+	t.startRename(pending)
+}
+
+func pathDepth(path string) int {
+	return strings.Count(filepath.Clean(path), string(filepath.Separator))
+}
+
+// renameResult 记录一次批量重命名的结果。renameItems 可以在后台执行，
+// 它不修改条目本身，而是把新路径和状态记录在这里，由 apply 在 UI 线程中写回。
+type renameResult struct {
+	paths           map[*FileItem]string // 路径发生变化的条目 (包括随文件夹改名的子条目)
+	status          map[*FileItem]string
+	errors          map[*FileItem]string // 失败条目的错误信息
+	previous        map[*FileItem]string // 成功移动的条目改名前的路径
+	renamed, failed int
+	skipped         int // 因任务取消而未处理的条目
+}
+
+func (r *renameResult) apply() {
+	for item, path := range r.paths {
+		item.OriginalPath = path
+		item.OriginalName = filepath.Base(path)
+	}
+	for item, status := range r.status {
+		item.Status = status
+		item.ErrorMessage = r.errors[item]
+	}
+	for item, path := range r.previous {
+		item.PreviousPath = path
+	}
+}
+
+// renameItems 把 pending 中的条目重命名为各自的 NewName，NewName 中的文件夹会按需创建。
+// copyMode 为 true 时复制到新位置，原文件保持不变。
+// 先重命名层级最深的条目，这样重命名父文件夹时子路径仍然有效；
+// 文件夹改名后，all 中位于其下的条目路径会随之更新。
+// 如果某个条目的当前路径是另一个条目的目标 (如 1->2, 2->3 或互换)，先把它移到临时名称腾出位置。
+// ctx 取消后不再处理新的条目，但已经移到临时名称的条目仍会完成重命名。
+func renameItems(ctx context.Context, pending, all []*FileItem, copyMode bool, progress func(done, total int)) *renameResult {
+	result := &renameResult{
+		paths:    make(map[*FileItem]string),
+		status:   make(map[*FileItem]string),
+		errors:   make(map[*FileItem]string),
+		previous: make(map[*FileItem]string),
+	}
+	current := func(item *FileItem) string {
+		if p, ok := result.paths[item]; ok {
+			return p
+		}
+		return item.OriginalPath
+	}
+	moved := func(item *FileItem, oldPath, newPath string) {
+		result.paths[item] = newPath
+		if item.IsDir {
+			rebaseChildren(all, oldPath, newPath, current, result.paths)
+		}
+	}
+
+	pending = append([]*FileItem(nil), pending...)
+	sort.SliceStable(pending, func(i, j int) bool { return pathDepth(pending[i].OriginalPath) > pathDepth(pending[j].OriginalPath) })
+	targets := make(map[string]bool, len(pending))
+	for _, item := range pending {
+		target := pathKey(targetPath(item))
+		if !copyMode && target != pathKey(item.OriginalPath) {
+			targets[target] = true
+		}
+	}
+
+	failed := make(map[*FileItem]bool)
+	tempMoved := make(map[*FileItem]bool)
+	for i, item := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		if !targets[pathKey(item.OriginalPath)] {
 			continue
 		}
-		newPath := filepath.Join(filepath.Dir(item.OriginalPath), item.NewName)
-		if err := os.Rename(item.OriginalPath, newPath); err != nil {
-			log.Printf("重命名失败: %s -> %s, 错误: %v", item.OriginalPath, newPath, err)
-			item.Status = "error"
-			errorCount++
-		} else {
-			item.Status = "success"
-			item.OriginalPath = newPath
-			item.OriginalName = item.NewName
-			renamedCount++
+		oldPath := current(item)
+		tempPath := filepath.Join(filepath.Dir(oldPath), fmt.Sprintf(".yanshu-rename-%d-%d", os.Getpid(), i))
+		if err := os.Rename(oldPath, tempPath); err != nil {
+			log.Printf("重命名失败: %s -> %s, 错误: %v", oldPath, tempPath, err)
+			result.status[item] = "error"
+			result.errors[item] = err.Error()
+			failed[item] = true
+			result.failed++
+			continue
+		}
+		tempMoved[item] = true
+		moved(item, oldPath, tempPath)
+	}
+
+	for i, item := range pending {
+		if progress != nil {
+			progress(i, len(pending))
+		}
+		if failed[item] {
+			continue
+		}
+		if ctx.Err() != nil && !tempMoved[item] {
+			result.skipped++
+			continue
+		}
+		oldPath := current(item)
+		newPath := resolveTarget(oldPath, item.NewName)
+		err := os.MkdirAll(filepath.Dir(newPath), 0755)
+		if err == nil {
+			if copyMode {
+				err = copyPath(oldPath, newPath)
+			} else {
+				err = os.Rename(oldPath, newPath)
+			}
+		}
+		if err != nil {
+			log.Printf("重命名失败: %s -> %s, 错误: %v", oldPath, newPath, err)
+			result.status[item] = "error"
+			result.errors[item] = err.Error()
+			result.failed++
+			continue
 		}
-		t.previewList.RefreshItem(i)
+		result.status[item] = "success"
+		result.renamed++
+		if !copyMode {
+			result.previous[item] = item.OriginalPath
+			moved(item, oldPath, newPath)
+		}
+	}
+	if progress != nil {
+		progress(len(pending), len(pending))
 	}
-	dialog.ShowInformation("完成", fmt.Sprintf("重命名完成。\n成功: %d\n失败: %d", renamedCount, errorCount), t.win)
+	return result
 }
 
-// --- Presets and Dialogs (无变化) ---
+// rebaseChildren 在文件夹改名后，把 items 中位于其下的条目的新路径记录到 paths
+func rebaseChildren(items []*FileItem, oldDir, newDir string, current func(*FileItem) string, paths map[*FileItem]string) {
+	prefix := oldDir + string(filepath.Separator)
+	for _, item := range items {
+		if p := current(item); strings.HasPrefix(p, prefix) {
+			paths[item] = filepath.Join(newDir, strings.TrimPrefix(p, prefix))
+		}
+	}
+}
+
+// findConflicts 检查待重命名的条目：新名称为空、多个条目得到相同的目标路径，
+// 或目标路径已被列表外的文件占用，都视为冲突。复制模式下源文件不会腾出位置，
+// 因此目标是另一个源文件也算冲突。
+func findConflicts(pending []*FileItem, copyMode bool) []string {
+	var conflicts []string
+	sources := make(map[string]bool, len(pending))
+	for _, item := range pending {
+		sources[pathKey(item.OriginalPath)] = true
+	}
+	targets := make(map[string]string, len(pending))
+	for _, item := range pending {
+		if strings.TrimSpace(item.NewName) == "" {
+			conflicts = append(conflicts, fmt.Sprintf("%s: 新名称为空", item.OriginalPath))
+			continue
+		}
+		target := targetPath(item)
+		key := pathKey(target)
+		if other, dup := targets[key]; dup {
+			conflicts = append(conflicts, fmt.Sprintf("%s 和 %s 都将被重命名为 %s", other, item.OriginalPath, target))
+			continue
+		}
+		targets[key] = item.OriginalPath
+		if copyMode && key == pathKey(item.OriginalPath) {
+			conflicts = append(conflicts, fmt.Sprintf("%s: 不能复制到自身", item.OriginalPath))
+			continue
+		}
+		if !copyMode && (key == pathKey(item.OriginalPath) || sources[key]) {
+			continue // 只改大小写，或目标本身也会被改名
+		}
+		if _, err := os.Lstat(target); err == nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s: 目标 %s 已存在", item.OriginalPath, target))
+		}
+	}
+	return conflicts
+}
+
+// pathKey 用于比较路径。Windows 和 macOS 的文件系统默认不区分大小写。
+func pathKey(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// --- Presets and Dialogs ---
 const presetsDir = "./data/renamer"
 
+// loadPresets 读取预设目录。旧格式的预设会被迁移并改写 (原文件备份为 .v1.bak)，
+// 无法加载的预设会在界面上提示。
 func (t *renamerTool) loadPresets() {
 	if err := os.MkdirAll(presetsDir, 0755); err != nil {
 		log.Printf("无法创建预设目录: %v", err)
 		return
 	}
-	t.presets = make(map[string][]RuleDefinition)
+	t.presets = make(map[string]Preset)
 	var presetNames []string
 	files, err := ioutil.ReadDir(presetsDir)
 	if err != nil {
 		log.Printf("无法读取预设目录: %v", err)
 		return
 	}
+	var problems []string
 	for _, file := range files {
 		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
 			presetName := strings.TrimSuffix(file.Name(), ".json")
 			filePath := filepath.Join(presetsDir, file.Name())
-			data, err := ioutil.ReadFile(filePath)
+			preset, migrated, err := readPresetFile(filePath)
+			if err == nil {
+				_, err = preset.BuildRules()
+			}
 			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s:\n%v", file.Name(), err))
 				continue
 			}
-			var defs []RuleDefinition
-			if err := json.Unmarshal(data, &defs); err == nil {
-				t.presets[presetName] = defs
-				presetNames = append(presetNames, presetName)
+			if migrated {
+				log.Printf("检测到旧版预设 %s，正在迁移...", file.Name())
+				if err := migratePresetFile(filePath, preset); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: 迁移失败: %v", file.Name(), err))
+				}
 			}
+			t.presets[presetName] = preset
+			presetNames = append(presetNames, presetName)
 		}
 	}
 	sort.Strings(presetNames)
 	t.presetSelect.Options = presetNames
 	t.presetSelect.Refresh()
+	if len(problems) > 0 {
+		dialog.ShowError(fmt.Errorf("以下预设无法加载:\n%s", strings.Join(problems, "\n")), t.win)
+	}
+}
+
+func migratePresetFile(path string, preset Preset) error {
+	original, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".v1.bak", original, 0644); err != nil {
+		return err
+	}
+	return writePresetFile(path, preset)
 }
 
 func (t *renamerTool) loadPreset(name string) {
-	if defs, ok := t.presets[name]; ok {
-		t.rules = []Rule{}
-		for _, def := range defs {
-			t.rules = append(t.rules, t.createRuleFromDef(def))
+	if preset, ok := t.presets[name]; ok {
+		rules, err := preset.BuildRules()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("预设 '%s' 无效:\n%v", name, err), t.win)
+			return
 		}
+		t.rules = rules
 		t.ruleList.Refresh()
 		t.updatePreviews()
 	}
 }
 
+// validatePresetName 确保预设名称可以直接用作文件名
+func validatePresetName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("预设名称不能为空")
+	}
+	if strings.ContainsAny(name, `/\:*?"<>|`) {
+		return fmt.Errorf("预设名称不能包含 / \\ : * ? \" < > |")
+	}
+	return nil
+}
+
 func (t *renamerTool) showSavePresetDialog() {
 	entry := widget.NewEntry()
 	entry.SetPlaceHolder("输入预设名称...")
+	entry.SetText(t.presetSelect.Selected)
+	entry.Validator = validatePresetName
 	d := dialog.NewForm("保存预设", "确定", "取消", []*widget.FormItem{widget.NewFormItem("名称", entry)}, func(ok bool) {
 		if ok && entry.Text != "" {
 			if len(t.rules) == 0 {
 				dialog.ShowInformation("提示", "没有可保存的规则。", t.win)
 				return
 			}
-			var defs []RuleDefinition
-			for _, r := range t.rules {
-				defs = append(defs, t.createDefFromRule(r))
-			}
-			data, err := json.MarshalIndent(defs, "", "  ")
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("无法序列化规则: %v", err), t.win)
-				return
-			}
 			filePath := filepath.Join(presetsDir, entry.Text+".json")
-			if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
-				dialog.ShowError(fmt.Errorf("无法保存预设文件: %v", err), t.win)
+			if err := writePresetFile(filePath, newPreset(entry.Text, t.rules)); err != nil {
+				dialog.ShowError(err, t.win)
 				return
 			}
 			dialog.ShowInformation("成功", fmt.Sprintf("预设 '%s' 已保存。", entry.Text), t.win)
@@ -474,44 +1247,91 @@ func (t *renamerTool) deleteCurrentPreset() {
 	}, t.win)
 }
 
-func (t *renamerTool) createRuleFromDef(def RuleDefinition) Rule {
-	switch def.Type {
-	case "replace":
-		return &ReplaceRule{Old: def.Param1, New: def.Param2}
-	case "insert":
-		pos, _ := strconv.Atoi(def.Param2)
-		return &InsertRule{Text: def.Param1, Position: pos}
-	case "case":
-		return &CaseRule{CaseType: def.Param1}
-	case "serialize":
-		start, _ := strconv.Atoi(def.Param1)
-		step, _ := strconv.Atoi(def.Param2)
-		padding, _ := strconv.Atoi(def.Param3)
-		pos, _ := strconv.Atoi(def.Param4)
-		return &SerializeRule{Start: start, Step: step, Padding: padding, Position: pos}
-	}
-	return nil
+// showImportPresetDialog 从单个文件导入预设，导入前会校验其中的全部规则
+func (t *renamerTool) showImportPresetDialog() {
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, t.win)
+			return
+		}
+		preset, _, err := parsePreset(data)
+		if err == nil {
+			_, err = preset.BuildRules()
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("无法导入预设:\n%v", err), t.win)
+			return
+		}
+		name := preset.Name
+		if validatePresetName(name) != nil {
+			name = strings.TrimSuffix(reader.URI().Name(), reader.URI().Extension())
+		}
+		preset.Name = name
+		save := func() {
+			if err := writePresetFile(filepath.Join(presetsDir, name+".json"), preset); err != nil {
+				dialog.ShowError(err, t.win)
+				return
+			}
+			t.loadPresets()
+			t.presetSelect.SetSelected(name)
+		}
+		if _, exists := t.presets[name]; exists {
+			dialog.ShowConfirm("覆盖预设", fmt.Sprintf("预设 '%s' 已存在，是否覆盖？", name), func(ok bool) {
+				if ok {
+					save()
+				}
+			}, t.win)
+			return
+		}
+		save()
+	}, t.win)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	d.Show()
 }
 
-func (t *renamerTool) createDefFromRule(rule Rule) RuleDefinition {
-	switch r := rule.(type) {
-	case *ReplaceRule:
-		return RuleDefinition{Type: "replace", Param1: r.Old, Param2: r.New}
-	case *InsertRule:
-		return RuleDefinition{Type: "insert", Param1: r.Text, Param2: fmt.Sprintf("%d", r.Position)}
-	case *CaseRule:
-		return RuleDefinition{Type: "case", Param1: r.CaseType}
-	case *SerializeRule:
-		return RuleDefinition{Type: "serialize", Param1: fmt.Sprintf("%d", r.Start), Param2: fmt.Sprintf("%d", r.Step), Param3: fmt.Sprintf("%d", r.Padding), Param4: fmt.Sprintf("%d", r.Position)}
+// showExportPresetDialog 把当前规则列表导出为单个预设文件，方便分享
+func (t *renamerTool) showExportPresetDialog() {
+	if len(t.rules) == 0 {
+		dialog.ShowInformation("提示", "没有可导出的规则。", t.win)
+		return
 	}
-	return RuleDefinition{}
+	name := t.presetSelect.Selected
+	if name == "" {
+		name = "重命名预设"
+	}
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		data, err := json.MarshalIndent(newPreset(name, t.rules), "", "  ")
+		if err == nil {
+			_, err = writer.Write(data)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("导出失败: %v", err), t.win)
+		}
+	}, t.win)
+	d.SetFileName(name + ".json")
+	d.Show()
 }
 
-func (t *renamerTool) showAddRuleDialog() {
-	ruleTypes := []string{"插入", "替换", "大小写", "序列化"}
+// showAddRuleDialog 显示规则编辑对话框。editIndex 为 -1 时添加新规则，否则预先填入该规则的参数进行编辑。
+func (t *renamerTool) showAddRuleDialog(editIndex int) {
+	var existing Rule
+	if editIndex >= 0 && editIndex < len(t.rules) {
+		existing = t.rules[editIndex]
+	}
+	ruleTypes := []string{"插入", "替换", "大小写", "序列化", "模板", "名称映射", "拼音", "扩展名", "目标文件夹"}
 	var ruleGetters []func() Rule
+	initialType := 0
 	configStack := container.NewStack()
-	for _, ruleType := range ruleTypes {
+	for typeIndex, ruleType := range ruleTypes {
 		var configUI fyne.CanvasObject
 		var getter func() Rule
 		switch ruleType {
@@ -520,6 +1340,13 @@ func (t *renamerTool) showAddRuleDialog() {
 			textEntry.SetPlaceHolder("要插入的文本")
 			posRadio := widget.NewRadioGroup([]string{"前缀", "后缀"}, nil)
 			posRadio.SetSelected("前缀")
+			if r, ok := existing.(*InsertRule); ok {
+				initialType = typeIndex
+				textEntry.SetText(r.Text)
+				if r.Position == -1 {
+					posRadio.SetSelected("后缀")
+				}
+			}
 			configUI = container.NewVBox(widget.NewForm(widget.NewFormItem("插入:", textEntry)), widget.NewForm(widget.NewFormItem("位置:", posRadio)))
 			getter = func() Rule {
 				pos := 0
@@ -533,20 +1360,48 @@ func (t *renamerTool) showAddRuleDialog() {
 			oldEntry.SetPlaceHolder("要被替换的文本")
 			newEntry := widget.NewEntry()
 			newEntry.SetPlaceHolder("替换后的新文本")
+			if r, ok := existing.(*ReplaceRule); ok {
+				initialType = typeIndex
+				oldEntry.SetText(r.Old)
+				newEntry.SetText(r.New)
+			}
 			configUI = widget.NewForm(widget.NewFormItem("查找:", oldEntry), widget.NewFormItem("替换为:", newEntry))
 			getter = func() Rule { return &ReplaceRule{Old: oldEntry.Text, New: newEntry.Text} }
 		case "大小写":
-			caseRadio := widget.NewRadioGroup([]string{"全部小写", "全部大写", "首字母大写"}, nil)
-			caseRadio.SetSelected("全部小写")
-			configUI = widget.NewForm(widget.NewFormItem("转换:", caseRadio))
+			caseSelect := widget.NewSelect(caseTypeLabels, nil)
+			caseSelect.SetSelectedIndex(0)
+			smallWordsEntry := widget.NewEntry()
+			smallWordsEntry.SetText(strings.Join(defaultSmallWords, ", "))
+			separatorsCheck := widget.NewCheck("把 _ - . 当作单词分隔符", nil)
+			if r, ok := existing.(*CaseRule); ok {
+				initialType = typeIndex
+				for i, c := range caseTypes {
+					if c == r.CaseType {
+						caseSelect.SetSelectedIndex(i)
+					}
+				}
+				smallWordsEntry.SetText(strings.Join(r.SmallWords, ", "))
+				separatorsCheck.SetChecked(r.Separators)
+			}
+			caseSelect.OnChanged = func(string) {
+				if caseTypes[caseSelect.SelectedIndex()] == "title" {
+					smallWordsEntry.Enable()
+				} else {
+					smallWordsEntry.Disable()
+				}
+			}
+			caseSelect.OnChanged(caseSelect.Selected)
+			configUI = widget.NewForm(
+				widget.NewFormItem("转换:", caseSelect),
+				widget.NewFormItem("保持小写的词:", smallWordsEntry),
+				widget.NewFormItem("", separatorsCheck),
+			)
 			getter = func() Rule {
-				caseType := "lower"
-				if caseRadio.Selected == "全部大写" {
-					caseType = "upper"
-				} else if caseRadio.Selected == "首字母大写" {
-					caseType = "title"
+				return &CaseRule{
+					CaseType:   caseTypes[caseSelect.SelectedIndex()],
+					SmallWords: splitList(smallWordsEntry.Text),
+					Separators: separatorsCheck.Checked,
 				}
-				return &CaseRule{CaseType: caseType}
 			}
 		case "序列化":
 			startEntry := widget.NewEntry()
@@ -557,7 +1412,33 @@ func (t *renamerTool) showAddRuleDialog() {
 			paddingEntry.SetText("2")
 			posRadio := widget.NewRadioGroup([]string{"前缀", "后缀"}, nil)
 			posRadio.SetSelected("前缀")
-			configUI = container.NewVBox(widget.NewForm(widget.NewFormItem("起始数字:", startEntry), widget.NewFormItem("步长:", stepEntry), widget.NewFormItem("补零位数:", paddingEntry)), widget.NewForm(widget.NewFormItem("位置:", posRadio)))
+			formatSelect := widget.NewSelect(serializeFormatLabels, nil)
+			formatSelect.SetSelectedIndex(0)
+			perFolderCheck := widget.NewCheck("每个文件夹重新计数", nil)
+			naturalCheck := widget.NewCheck("按文件名自然顺序编号", nil)
+			countDownCheck := widget.NewCheck("倒序编号", nil)
+			if r, ok := existing.(*SerializeRule); ok {
+				initialType = typeIndex
+				startEntry.SetText(strconv.Itoa(r.Start))
+				stepEntry.SetText(strconv.Itoa(r.Step))
+				paddingEntry.SetText(strconv.Itoa(r.Padding))
+				if r.Position == -1 {
+					posRadio.SetSelected("后缀")
+				}
+				for i, f := range serializeFormats {
+					if f == r.Format {
+						formatSelect.SetSelectedIndex(i)
+					}
+				}
+				perFolderCheck.SetChecked(r.PerFolder)
+				naturalCheck.SetChecked(r.Natural)
+				countDownCheck.SetChecked(r.CountDown)
+			}
+			configUI = container.NewVBox(
+				widget.NewForm(widget.NewFormItem("起始数字:", startEntry), widget.NewFormItem("步长:", stepEntry), widget.NewFormItem("补零位数:", paddingEntry), widget.NewFormItem("格式:", formatSelect)),
+				widget.NewForm(widget.NewFormItem("位置:", posRadio)),
+				perFolderCheck, naturalCheck, countDownCheck,
+			)
 			getter = func() Rule {
 				start, _ := strconv.Atoi(startEntry.Text)
 				step, _ := strconv.Atoi(stepEntry.Text)
@@ -566,7 +1447,139 @@ func (t *renamerTool) showAddRuleDialog() {
 				if posRadio.Selected == "后缀" {
 					pos = -1
 				}
-				return &SerializeRule{Start: start, Step: step, Padding: padding, Position: pos}
+				return &SerializeRule{Start: start, Step: step, Padding: padding, Position: pos,
+					PerFolder: perFolderCheck.Checked, Natural: naturalCheck.Checked, CountDown: countDownCheck.Checked,
+					Format: serializeFormats[formatSelect.SelectedIndex()]}
+			}
+		case "模板":
+			templateEntry := widget.NewEntry()
+			templateEntry.SetText("{name}")
+			if r, ok := existing.(*TemplateRule); ok {
+				initialType = typeIndex
+				templateEntry.SetText(r.Template)
+			}
+			help := widget.NewLabel("可用标记:\n{name} 当前名称  {parent} 所在文件夹  {index:03} 序号(补零)\n{mtime:2006-01-02} 修改时间  {width}x{height} 图片尺寸\n{exif:DateTimeOriginal:2006-01-02} EXIF 信息\n{id3:artist} 音频标签 (title/artist/album/year/track/genre)")
+			help.Wrapping = fyne.TextWrapWord
+			configUI = container.NewVBox(widget.NewForm(widget.NewFormItem("模板:", templateEntry)), help)
+			getter = func() Rule { return &TemplateRule{Template: templateEntry.Text} }
+		case "名称映射":
+			modeRadio := widget.NewRadioGroup([]string{"按顺序", "按原名称匹配"}, nil)
+			modeRadio.Horizontal = true
+			mappingEntry := widget.NewMultiLineEntry()
+			mappingEntry.SetMinRowsVisible(8)
+			modeRadio.OnChanged = func(mode string) {
+				if mode == "按原名称匹配" {
+					mappingEntry.SetPlaceHolder("每行一条: 原名称<Tab>新名称\n原名称可以包含或不含扩展名")
+				} else {
+					mappingEntry.SetPlaceHolder("每行一个新名称，按预览中的顺序对应")
+				}
+			}
+			modeRadio.SetSelected("按顺序")
+			if r, ok := existing.(*MappingRule); ok {
+				initialType = typeIndex
+				if r.ByKey {
+					modeRadio.SetSelected("按原名称匹配")
+				}
+				mappingEntry.SetText(formatMappingText(r.Pairs, r.ByKey))
+			}
+			importBtn := widget.NewButtonWithIcon("从 CSV/TSV 导入...", theme.FolderOpenIcon(), func() {
+				t.showImportMappingDialog(modeRadio.Selected == "按原名称匹配", mappingEntry.SetText)
+			})
+			configUI = container.NewBorder(container.NewBorder(nil, nil, nil, importBtn, modeRadio), nil, nil, nil, mappingEntry)
+			getter = func() Rule {
+				byKey := modeRadio.Selected == "按原名称匹配"
+				pairs, err := parseMappingText(mappingEntry.Text, byKey)
+				if err != nil {
+					dialog.ShowError(err, t.win)
+					return nil
+				}
+				return &MappingRule{ByKey: byKey, Pairs: pairs}
+			}
+		case "拼音":
+			toneSelect := widget.NewSelect(pinyinToneLabels, nil)
+			toneSelect.SetSelectedIndex(0)
+			initialsCheck := widget.NewCheck("只保留首字母", func(checked bool) {
+				if checked {
+					toneSelect.Disable()
+				} else {
+					toneSelect.Enable()
+				}
+			})
+			separatorEntry := widget.NewEntry()
+			separatorEntry.SetPlaceHolder("留空则不分隔，例如 _ 或空格")
+			simplifyCheck := widget.NewCheck("先把繁体字转为简体", nil)
+			if r, ok := existing.(*PinyinRule); ok {
+				initialType = typeIndex
+				for i, tone := range pinyinTones {
+					if tone == r.Tone {
+						toneSelect.SetSelectedIndex(i)
+					}
+				}
+				initialsCheck.SetChecked(r.Initials)
+				separatorEntry.SetText(r.Separator)
+				simplifyCheck.SetChecked(r.Simplify)
+			}
+			configUI = widget.NewForm(
+				widget.NewFormItem("声调:", toneSelect),
+				widget.NewFormItem("", initialsCheck),
+				widget.NewFormItem("分隔符:", separatorEntry),
+				widget.NewFormItem("", simplifyCheck),
+			)
+			getter = func() Rule {
+				return &PinyinRule{
+					Tone:      pinyinTones[toneSelect.SelectedIndex()],
+					Initials:  initialsCheck.Checked,
+					Separator: separatorEntry.Text,
+					Simplify:  simplifyCheck.Checked,
+				}
+			}
+		case "扩展名":
+			modeRadio := widget.NewRadioGroup([]string{"修改为", "全部小写", "全部大写"}, nil)
+			extEntry := widget.NewEntry()
+			extEntry.SetPlaceHolder("新扩展名，例如 jpg (留空则移除扩展名)")
+			modeRadio.OnChanged = func(mode string) {
+				if mode == "修改为" {
+					extEntry.Enable()
+				} else {
+					extEntry.Disable()
+				}
+			}
+			modeRadio.SetSelected("修改为")
+			if r, ok := existing.(*ExtensionRule); ok {
+				initialType = typeIndex
+				extEntry.SetText(r.NewExt)
+				switch r.Mode {
+				case "lower":
+					modeRadio.SetSelected("全部小写")
+				case "upper":
+					modeRadio.SetSelected("全部大写")
+				}
+			}
+			configUI = widget.NewForm(widget.NewFormItem("操作:", modeRadio), widget.NewFormItem("扩展名:", extEntry))
+			getter = func() Rule {
+				mode := "set"
+				if modeRadio.Selected == "全部小写" {
+					mode = "lower"
+				} else if modeRadio.Selected == "全部大写" {
+					mode = "upper"
+				}
+				return &ExtensionRule{ruleBase: ruleBase{Scope: ScopeExt}, Mode: mode, NewExt: strings.TrimSpace(extEntry.Text)}
+			}
+		case "目标文件夹":
+			folderEntry := widget.NewEntry()
+			folderEntry.SetText("{mtime:2006}/{mtime:01}")
+			baseEntry := widget.NewEntry()
+			baseEntry.SetPlaceHolder("留空则相对于文件当前所在的文件夹")
+			if r, ok := existing.(*FolderRule); ok {
+				initialType = typeIndex
+				folderEntry.SetText(r.Template)
+				baseEntry.SetText(r.Base)
+			}
+			help := widget.NewLabel("用 / 分隔多级文件夹，文件夹不存在时会自动创建。可用标记与模板规则相同，例如:\n{mtime:2006}/{mtime:01} 按修改年月整理\n{exif:DateTimeOriginal:2006/01} 按拍摄年月整理\n之后的规则只作用于文件名部分。")
+			help.Wrapping = fyne.TextWrapWord
+			configUI = container.NewVBox(widget.NewForm(widget.NewFormItem("文件夹:", folderEntry), widget.NewFormItem("基准文件夹:", baseEntry)), help)
+			getter = func() Rule {
+				return &FolderRule{ruleBase: ruleBase{Scope: ScopeFull}, Template: folderEntry.Text, Base: strings.TrimSpace(baseEntry.Text)}
 			}
 		}
 		configStack.Add(configUI)
@@ -574,9 +1587,21 @@ func (t *renamerTool) showAddRuleDialog() {
 		ruleGetters = append(ruleGetters, getter)
 	}
 	var selectedIndex int
+	scopeSelect := widget.NewSelect(scopeLabels, nil)
+	scopeSelect.SetSelectedIndex(int(ScopeName))
+	if existing != nil {
+		scopeSelect.SetSelectedIndex(int(existing.options().Scope))
+	}
+	scopeRow := widget.NewForm(widget.NewFormItem("作用范围:", scopeSelect))
 	typeList := widget.NewList(func() int { return len(ruleTypes) }, func() fyne.CanvasObject { return widget.NewLabel("") }, func(id widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(ruleTypes[id]) })
 	typeList.OnSelected = func(id widget.ListItemID) {
 		selectedIndex = id
+		// 扩展名规则固定作用于扩展名，目标文件夹规则固定作用于完整名称
+		if ruleTypes[id] == "扩展名" || ruleTypes[id] == "目标文件夹" {
+			scopeRow.Hide()
+		} else {
+			scopeRow.Show()
+		}
 		for i, obj := range configStack.Objects {
 			if i == id {
 				obj.Show()
@@ -586,33 +1611,120 @@ func (t *renamerTool) showAddRuleDialog() {
 		}
 		configStack.Refresh()
 	}
-	typeList.Select(0)
-	content := container.NewHSplit(typeList, configStack)
+	typeList.Select(initialType)
+	content := container.NewHSplit(typeList, container.NewBorder(nil, scopeRow, nil, nil, container.NewVScroll(configStack)))
 	content.SetOffset(0.3)
-	d := dialog.NewCustomConfirm("添加规则", "添加", "取消", content, func(ok bool) {
-		if !ok {
-			return
-		}
+	title, confirm := "添加规则", "添加"
+	if existing != nil {
+		title, confirm = "编辑规则", "保存"
+	}
+	// 不使用 NewCustomConfirm：参数有误时对话框需要保持打开，已输入的内容 (如粘贴的映射表) 不会丢失
+	var d *dialog.CustomDialog
+	cancelBtn := widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() { d.Hide() })
+	confirmBtn := widget.NewButtonWithIcon(confirm, theme.ConfirmIcon(), func() {
 		newRule := ruleGetters[selectedIndex]()
-		if newRule != nil {
-			t.addRule(newRule)
+		if newRule == nil {
+			return // getter 已经提示了错误
 		}
-	}, t.win)
-	d.Resize(fyne.NewSize(500, 300))
+		d.Hide()
+		if ruleTypes[selectedIndex] != "扩展名" && ruleTypes[selectedIndex] != "目标文件夹" {
+			newRule.options().Scope = RuleScope(scopeSelect.SelectedIndex())
+		}
+		if existing != nil {
+			newRule.options().Disabled = existing.options().Disabled
+			t.rules[editIndex] = newRule
+			t.ruleList.Refresh()
+			t.updatePreviews()
+			return
+		}
+		t.addRule(newRule)
+	})
+	confirmBtn.Importance = widget.HighImportance
+	d = dialog.NewCustomWithoutButtons(title, content, t.win)
+	d.SetButtons([]fyne.CanvasObject{cancelBtn, confirmBtn})
+	d.Resize(fyne.NewSize(560, 440))
 	d.Show()
 }
 
+// ruleRow 是规则列表中的一行：启用复选框 + 规则描述，双击可编辑。
+type ruleRow struct {
+	widget.BaseWidget
+	check          *widget.Check
+	label          *widget.Label
+	onTapped       func()
+	onDoubleTapped func()
+}
+
+func newRuleRow() *ruleRow {
+	r := &ruleRow{check: widget.NewCheck("", nil), label: widget.NewLabel("")}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+// Set 更新行内容。列表项会被复用，所以先解绑旧回调再设置勾选状态。
+func (r *ruleRow) Set(text string, enabled bool, onToggle func(bool)) {
+	r.label.SetText(text)
+	if enabled {
+		r.label.Importance = widget.MediumImportance
+	} else {
+		r.label.Importance = widget.LowImportance
+	}
+	r.label.Refresh()
+	r.check.OnChanged = nil
+	r.check.SetChecked(enabled)
+	r.check.OnChanged = onToggle
+}
+
+func (r *ruleRow) Tapped(*fyne.PointEvent) {
+	if r.onTapped != nil {
+		r.onTapped()
+	}
+}
+
+func (r *ruleRow) DoubleTapped(*fyne.PointEvent) {
+	if r.onDoubleTapped != nil {
+		r.onDoubleTapped()
+	}
+}
+
+func (r *ruleRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, r.check, nil, r.label))
+}
+
 type coloredLabel struct {
 	widget.BaseWidget
+	check                    *widget.Check
+	kindIcon                 *widget.Icon
 	original, arrow, newPart *canvas.Text
+	detail                   *canvas.Text
 	statusIcon               *widget.Icon
 }
 
 func newColoredLabel() *coloredLabel {
-	c := &coloredLabel{original: canvas.NewText("", theme.ForegroundColor()), arrow: canvas.NewText("  ->  ", theme.ForegroundColor()), newPart: canvas.NewText("", color.NRGBA{R: 255, A: 255}), statusIcon: widget.NewIcon(nil)}
+	c := &coloredLabel{check: widget.NewCheck("", nil), kindIcon: widget.NewIcon(theme.FileIcon()), original: canvas.NewText("", theme.ForegroundColor()), arrow: canvas.NewText("  ->  ", theme.ForegroundColor()), newPart: canvas.NewText("", color.NRGBA{R: 255, A: 255}), detail: canvas.NewText("", theme.DisabledColor()), statusIcon: widget.NewIcon(nil)}
 	c.ExtendBaseWidget(c)
 	return c
 }
+
+// SetIncluded 设置勾选状态。列表项会被复用，所以先解绑旧回调再设置，避免误触发。
+func (c *coloredLabel) SetIncluded(included bool, onChanged func(bool)) {
+	c.check.OnChanged = nil
+	c.check.SetChecked(included)
+	c.check.OnChanged = onChanged
+}
+
+func (c *coloredLabel) SetIsDir(isDir bool) {
+	if isDir {
+		c.kindIcon.SetResource(theme.FolderIcon())
+	} else {
+		c.kindIcon.SetResource(theme.FileIcon())
+	}
+}
+
+func (c *coloredLabel) SetDetail(text string) {
+	c.detail.Text = text
+	c.detail.Refresh()
+}
 func (c *coloredLabel) SetText(original, new, status string) {
 	c.original.Text = original
 	if original != new {
@@ -635,5 +1747,5 @@ func (c *coloredLabel) SetText(original, new, status string) {
 	c.Refresh()
 }
 func (c *coloredLabel) CreateRenderer() fyne.WidgetRenderer {
-	return widget.NewSimpleRenderer(container.NewHBox(c.original, c.arrow, c.newPart, layout.NewSpacer(), c.statusIcon))
+	return widget.NewSimpleRenderer(container.NewHBox(c.check, c.kindIcon, c.original, c.arrow, c.newPart, layout.NewSpacer(), c.detail, c.statusIcon))
 }
@@ -0,0 +1,280 @@
+package renamer_tool
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSerializeRule(t *testing.T) {
+	// 预览顺序中的文件: 两个文件夹交错出现，名称不是自然顺序
+	paths := []string{"a/10.txt", "b/x.txt", "a/2.txt", "b/y.txt", "a/1.txt"}
+	tests := []struct {
+		name string
+		rule SerializeRule
+		want []string
+	}{
+		{
+			name: "预览顺序",
+			rule: SerializeRule{Start: 1, Step: 1, Padding: 2},
+			want: []string{"0110.txt", "02x.txt", "032.txt", "04y.txt", "051.txt"},
+		},
+		{
+			name: "每个文件夹重新计数",
+			rule: SerializeRule{Start: 1, Step: 1, Position: -1, PerFolder: true},
+			want: []string{"101.txt", "x1.txt", "22.txt", "y2.txt", "13.txt"},
+		},
+		{
+			name: "自然顺序",
+			rule: SerializeRule{Start: 1, Step: 1, PerFolder: true, Natural: true},
+			want: []string{"310.txt", "1x.txt", "22.txt", "2y.txt", "11.txt"},
+		},
+		{
+			name: "倒序",
+			rule: SerializeRule{Start: 0, Step: 10, CountDown: true},
+			want: []string{"4010.txt", "30x.txt", "202.txt", "10y.txt", "01.txt"},
+		},
+		{
+			name: "字母",
+			rule: SerializeRule{Start: 25, Step: 1, Format: "upper"},
+			want: []string{"Y10.txt", "Zx.txt", "AA2.txt", "ABy.txt", "AC1.txt"},
+		},
+		{
+			name: "小写罗马数字",
+			rule: SerializeRule{Start: 3, Step: 1, Format: "roman-lower"},
+			want: []string{"iii10.txt", "ivx.txt", "v2.txt", "viy.txt", "vii1.txt"},
+		},
+		{
+			name: "无法表示的数字退回为普通数字",
+			rule: SerializeRule{Start: 0, Step: 1, Padding: 2, Format: "roman"},
+			want: []string{"0010.txt", "Ix.txt", "II2.txt", "IIIy.txt", "IV1.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []*FileItem
+			for _, p := range paths {
+				p = filepath.FromSlash(p)
+				items = append(items, &FileItem{OriginalPath: p, OriginalName: filepath.Base(p)})
+			}
+			rule := tt.rule
+			computeNewNames([]Rule{&rule}, items, nil)
+			var got []string
+			for _, item := range items {
+				got = append(got, item.NewName)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("新名称 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// renameFixture 在临时目录中创建文件 (以 / 结尾的是文件夹)，文件内容就是它的相对路径
+func renameFixture(t *testing.T, paths ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, p := range paths {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(p), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// snapshot 返回目录中所有文件的相对路径和内容，用于比较重命名后的结果
+func snapshot(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	return files
+}
+
+func TestRenameItems(t *testing.T) {
+	type rename struct{ from, to string }
+	tests := []struct {
+		name     string
+		files    []string
+		renames  []rename
+		others   []string // 在列表中但不重命名的条目
+		copyMode bool
+		want     map[string]string // 重命名后的文件 -> 原来的路径 (文件内容)
+		wantPath map[string]string // 条目 -> 记录在结果中的新路径
+	}{
+		{
+			name:    "互换",
+			files:   []string{"a.txt", "b.txt"},
+			renames: []rename{{"a.txt", "b.txt"}, {"b.txt", "a.txt"}},
+			want:    map[string]string{"a.txt": "b.txt", "b.txt": "a.txt"},
+		},
+		{
+			name:    "链式 1->2, 2->3",
+			files:   []string{"1", "2"},
+			renames: []rename{{"1", "2"}, {"2", "3"}},
+			want:    map[string]string{"2": "1", "3": "2"},
+		},
+		{
+			name:    "三个文件轮换",
+			files:   []string{"a", "b", "c"},
+			renames: []rename{{"a", "b"}, {"b", "c"}, {"c", "a"}},
+			want:    map[string]string{"a": "c", "b": "a", "c": "b"},
+		},
+		{
+			name:     "先重命名子条目再重命名文件夹",
+			files:    []string{"dir/x.txt", "dir/keep.txt"},
+			renames:  []rename{{"dir", "renamed"}, {"dir/x.txt", "y.txt"}},
+			others:   []string{"dir/keep.txt"},
+			want:     map[string]string{"renamed/y.txt": "dir/x.txt", "renamed/keep.txt": "dir/keep.txt"},
+			wantPath: map[string]string{"dir": "renamed", "dir/x.txt": "renamed/y.txt", "dir/keep.txt": "renamed/keep.txt"},
+		},
+		{
+			name:    "文件夹互换时子条目跟随",
+			files:   []string{"p/f", "q/g"},
+			renames: []rename{{"p", "q"}, {"q", "p"}},
+			others:  []string{"p/f", "q/g"},
+			want:    map[string]string{"q/f": "p/f", "p/g": "q/g"},
+			// 子条目经过临时名称后仍然指向最终位置
+			wantPath: map[string]string{"p": "q", "q": "p", "p/f": "q/f", "q/g": "p/g"},
+		},
+		{
+			name:    "按需创建目标文件夹",
+			files:   []string{"a.jpg"},
+			renames: []rename{{"a.jpg", "2024/05/a.jpg"}},
+			want:    map[string]string{"2024/05/a.jpg": "a.jpg"},
+		},
+		{
+			name:     "复制模式保留原文件",
+			files:    []string{"a", "b"},
+			renames:  []rename{{"a", "c"}, {"b", "sub/d"}},
+			copyMode: true,
+			want:     map[string]string{"a": "a", "b": "b", "c": "a", "sub/d": "b"},
+			wantPath: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := renameFixture(t, tt.files...)
+			items := map[string]*FileItem{}
+			item := func(rel string) *FileItem {
+				if it, ok := items[rel]; ok {
+					return it
+				}
+				p := filepath.Join(dir, filepath.FromSlash(rel))
+				info, err := os.Stat(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				it := &FileItem{OriginalPath: p, OriginalName: filepath.Base(p), NewName: filepath.Base(p), IsDir: info.IsDir()}
+				items[rel] = it
+				return it
+			}
+			var pending, all []*FileItem
+			for _, r := range tt.renames {
+				it := item(r.from)
+				it.NewName = filepath.FromSlash(r.to)
+				pending = append(pending, it)
+				all = append(all, it)
+			}
+			for _, rel := range tt.others {
+				all = append(all, item(rel))
+			}
+
+			var progress []int
+			result := renameItems(context.Background(), pending, all, tt.copyMode, func(done, total int) { progress = append(progress, done) })
+			if result.failed != 0 || result.renamed != len(pending) {
+				t.Fatalf("renamed=%d failed=%d errors=%v", result.renamed, result.failed, result.errors)
+			}
+			if !sort.IntsAreSorted(progress) || progress[len(progress)-1] != len(pending) {
+				t.Errorf("进度 = %v", progress)
+			}
+			got := snapshot(t, dir)
+			if len(got) != len(tt.want) {
+				t.Errorf("文件 = %v, 期望 %v", got, tt.want)
+			}
+			for name, from := range tt.want {
+				if got[name] != from {
+					t.Errorf("%s 的内容 = %q, 期望来自 %q (全部: %v)", name, got[name], from, got)
+				}
+			}
+			for name := range got {
+				if strings.Contains(name, ".yanshu-rename-") {
+					t.Errorf("留下了临时文件 %s", name)
+				}
+			}
+			if tt.wantPath != nil {
+				if len(result.paths) != len(tt.wantPath) {
+					t.Errorf("记录了 %d 个新路径, 期望 %d", len(result.paths), len(tt.wantPath))
+				}
+				for rel, want := range tt.wantPath {
+					if got := result.paths[items[rel]]; got != filepath.Join(dir, filepath.FromSlash(want)) {
+						t.Errorf("%s 的新路径 = %s, 期望 %s", rel, got, want)
+					}
+				}
+			}
+
+			result.apply()
+			for _, it := range pending {
+				if it.Status != "success" {
+					t.Errorf("%s 的状态 = %q", it.OriginalName, it.Status)
+				}
+				if _, err := os.Stat(it.OriginalPath); err != nil {
+					t.Errorf("apply 之后的路径不存在: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRenameItemsFailureAndCancel(t *testing.T) {
+	dir := renameFixture(t, "a", "b", "blocker")
+	newItem := func(name, newName string) *FileItem {
+		return &FileItem{OriginalPath: filepath.Join(dir, name), OriginalName: name, NewName: newName}
+	}
+
+	// 目标文件夹的位置是一个文件，无法创建；其他条目不受影响
+	bad, good := newItem("a", filepath.Join("blocker", "a")), newItem("b", "c")
+	result := renameItems(context.Background(), []*FileItem{bad, good}, nil, false, nil)
+	if result.failed != 1 || result.renamed != 1 || result.status[bad] != "error" || result.errors[bad] == "" {
+		t.Errorf("失败的条目: failed=%d renamed=%d status=%q", result.failed, result.renamed, result.status[bad])
+	}
+	if result.status[good] != "success" || result.previous[good] != good.OriginalPath {
+		t.Errorf("成功的条目: status=%q previous=%q", result.status[good], result.previous[good])
+	}
+
+	// 取消后不再处理任何条目
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result = renameItems(ctx, []*FileItem{newItem("a", "x"), newItem("blocker", "y")}, nil, false, nil)
+	if result.skipped != 2 || result.renamed != 0 {
+		t.Errorf("取消后: skipped=%d renamed=%d", result.skipped, result.renamed)
+	}
+	if want := map[string]string{"a": "a", "blocker": "blocker", "c": "b"}; !maps.Equal(snapshot(t, dir), want) {
+		t.Errorf("取消后文件被改动: %v", snapshot(t, dir))
+	}
+}
@@ -0,0 +1,93 @@
+package renamer_tool
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// reportRow 是导出报告中的一行
+type reportRow struct {
+	OriginalPath string `json:"original_path"`
+	NewName      string `json:"new_name"`
+	NewPath      string `json:"new_path"`
+	Status       string `json:"status"` // unchanged, pending, excluded, success, error
+	Error        string `json:"error,omitempty"`
+}
+
+var reportHeader = []string{"original_path", "new_name", "new_path", "status", "error"}
+
+func newReportRow(item *FileItem) reportRow {
+	row := reportRow{OriginalPath: item.OriginalPath, NewName: item.NewName, NewPath: targetPath(item), Status: item.Status, Error: item.ErrorMessage}
+	if item.PreviousPath != "" {
+		// 已经移动过的条目，OriginalPath 是新位置
+		row.OriginalPath, row.NewPath = item.PreviousPath, item.OriginalPath
+	}
+	if row.Status == "" {
+		switch {
+		case item.Excluded:
+			row.Status = "excluded"
+		case item.NewName != item.OriginalName:
+			row.Status = "pending"
+		default:
+			row.Status = "unchanged"
+		}
+	}
+	return row
+}
+
+// writeReport 把条目写成 CSV 或 JSON。CSV 带有 UTF-8 BOM，方便直接用 Excel 打开中文内容。
+func writeReport(w io.Writer, items []*FileItem, format string) error {
+	rows := make([]reportRow, len(items))
+	for i, item := range items {
+		rows[i] = newReportRow(item)
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(reportHeader)
+	for _, r := range rows {
+		cw.Write([]string{r.OriginalPath, r.NewName, r.NewPath, r.Status, r.Error})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// showExportReportDialog 导出列表中的全部文件 (包括被过滤掉的)，格式由扩展名 .csv 或 .json 决定
+func (t *renamerTool) showExportReportDialog() {
+	if len(t.fileItems) == 0 {
+		dialog.ShowInformation("提示", "文件列表为空。", t.win)
+		return
+	}
+	items := append([]*FileItem(nil), t.fileItems...)
+	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		format := "csv"
+		if strings.EqualFold(writer.URI().Extension(), ".json") {
+			format = "json"
+		}
+		if err := writeReport(writer, items, format); err != nil {
+			dialog.ShowError(fmt.Errorf("导出报告失败: %v", err), t.win)
+			return
+		}
+		dialog.ShowInformation("导出成功", fmt.Sprintf("已导出 %d 条记录到\n%s", len(items), writer.URI().Path()), t.win)
+	}, t.win)
+	fd.SetFileName("rename-report.csv")
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".json"}))
+	fd.Show()
+}
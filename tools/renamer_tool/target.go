@@ -0,0 +1,130 @@
+package renamer_tool
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FolderRule 把文件移动 (或复制) 到由模板生成的子文件夹中，例如 "{mtime:2006}/{mtime:01}"
+// 会把文件整理到 "2024/05/" 这样的文件夹。模板中的 '/' 是文件夹分隔符，可用的标记与模板规则相同。
+// Base 为空时目标文件夹相对于文件当前所在的文件夹，否则相对于 Base。
+type FolderRule struct {
+	ruleBase
+	Template string
+	Base     string
+}
+
+// invalidFolderChars 与 invalidNameChars 相同，但保留文件夹分隔符
+var invalidFolderChars = strings.NewReplacer(":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+func (r *FolderRule) Apply(original string, item *FileItem, index int) string {
+	folder := templateTokenRe.ReplaceAllStringFunc(r.Template, func(token string) string {
+		value, ok := resolveTemplateToken(token[1:len(token)-1], original, item, index)
+		if !ok {
+			return token
+		}
+		return invalidFolderChars.Replace(value)
+	})
+	// 去掉空的、"." 和 ".." 部分，目标不会跑到基准文件夹之外
+	var parts []string
+	for _, part := range strings.FieldsFunc(folder, isPathSeparator) {
+		part = strings.TrimSpace(part)
+		if part != "" && part != "." && part != ".." {
+			parts = append(parts, invalidFolderChars.Replace(part))
+		}
+	}
+	if r.Base != "" {
+		parts = append([]string{filepath.Clean(r.Base)}, parts...)
+	}
+	if len(parts) == 0 {
+		return original
+	}
+	return filepath.Join(append(parts, original)...)
+}
+
+func (r *FolderRule) Describe() string {
+	if r.Base != "" {
+		return fmt.Sprintf("移动到文件夹: '%s' (位于 %s)", r.Template, r.Base)
+	}
+	return fmt.Sprintf("移动到文件夹: '%s'", r.Template)
+}
+
+func isPathSeparator(c rune) bool {
+	return c == '/' || c == filepath.Separator
+}
+
+// splitTargetDir 把规则输出的名称拆分为文件夹部分和文件名部分
+func splitTargetDir(name string) (dir, base string) {
+	i := strings.LastIndexFunc(name, isPathSeparator)
+	if i < 0 {
+		return "", name
+	}
+	return name[:i+1], name[i+1:]
+}
+
+// targetPath 返回条目重命名后的完整路径。NewName 可以包含文件夹，
+// 相对路径以条目当前所在的文件夹为基准。
+func targetPath(item *FileItem) string {
+	return resolveTarget(item.OriginalPath, item.NewName)
+}
+
+func resolveTarget(currentPath, newName string) string {
+	if filepath.IsAbs(newName) {
+		return filepath.Clean(newName)
+	}
+	return filepath.Join(filepath.Dir(currentPath), newName)
+}
+
+// copyPath 把文件或整个文件夹复制到 dst，保留权限和修改时间。dst 已存在时失败。
+func copyPath(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst, info)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("目标 %s 已存在", dst)
+	}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return copyFile(p, target, info)
+	})
+}
+
+func copyFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
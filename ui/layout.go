@@ -48,6 +48,13 @@ func CreateMainWindowLayout(app fyne.App, win fyne.Window) fyne.CanvasObject {
 		}
 	}
 
+	// 拖入的文件交给当前标签页的工具
+	win.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
+		if target, ok := tabToolMap[mainContent.Selected()].(core.DropTarget); ok {
+			target.OnDropped(pos, uris)
+		}
+	})
+
 	sidebar := createSidebar(mainContent, win)
 
 	split := container.NewHSplit(sidebar, mainContent)
@@ -40,6 +40,7 @@ var SidebarLayout = []CategoryLayout{
 		Name: "文件管理",
 		ToolTitles: []string{
 			"批量重命名",
+			"重复文件查找",
 		},
 	},
 	{